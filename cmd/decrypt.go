@@ -14,15 +14,22 @@ import (
 // decrypt.
 var (
 	aesKey                string
+	aesKeyFile            string
 	aesKeySecretNamespace string
 	aesKeySecretName      string
 	aesKeySecretKey       string
+	aesKeyring            []string
+	ageIdentities         []string
+	archiveOutput         string
+	decryptStdout         bool
+	decryptOutputDir      string
+	decryptVerify         bool
 
 	decrypt = &cobra.Command{
-		Use:   "decrypt <file-path(s)>",
+		Use:   "decrypt <file-directory-or-archive-path(s)>",
 		Short: "Decrypt secrets in exported resource files",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			key, err := evaluateAesKey(cmd)
+			kr, err := evaluateKeyring(cmd)
 			if err != nil {
 				return err
 			}
@@ -32,7 +39,12 @@ var (
 				JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
 			}
 
-			return types.Decrypt(printFlags, key, args...)
+			return types.Decrypt(printFlags, kr, types.DecryptOptions{
+				ArchiveOut: archiveOutput,
+				Stdout:     decryptStdout,
+				OutputDir:  decryptOutputDir,
+				Verify:     decryptVerify,
+			}, args...)
 		},
 	}
 )
@@ -41,12 +53,19 @@ func evaluateAesKey(cmd *cobra.Command) (key string, err error) {
 	// 	use flag aes key value
 	key = aesKey
 
+	if aesKeyFile != "" {
+		key, err = types.ReadKeyFile(aesKeyFile)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	if k, ok := os.LookupEnv(types.EnvAesKey); ok {
 		key = k
 	}
 
 	if aesKeySecretNamespace != "" && aesKeySecretName != "" && aesKeySecretKey != "" {
-		config, err := readConfig(cmd, configFlags, printFlags)
+		config, err := loadConfig(cmd, configFlags, printFlags)
 		if err != nil {
 			return "", err
 		}
@@ -56,7 +75,7 @@ func evaluateAesKey(cmd *cobra.Command) (key string, err error) {
 		}
 	}
 
-	if key == "" {
+	if key == "" && len(aesKeyring) == 0 && len(ageIdentities) == 0 {
 		key, err = readKey()
 		if err != nil {
 			return "", err
@@ -65,13 +84,45 @@ func evaluateAesKey(cmd *cobra.Command) (key string, err error) {
 	return key, nil
 }
 
+// usesAesKeyFlags returns true if the aes key is provided via flag, key file or secret.
+func usesAesKeyFlags() bool {
+	return aesKey != "" || aesKeyFile != "" ||
+		(aesKeySecretNamespace != "" && aesKeySecretName != "" && aesKeySecretKey != "")
+}
+
+// evaluateKeyring creates the keyring of the aes key, the additional aes keys and the age identities.
+func evaluateKeyring(cmd *cobra.Command) (*types.Keyring, error) {
+	key, err := evaluateAesKey(cmd)
+	if err != nil {
+		return nil, err
+	}
+	kr, err := types.NewKeyring(append([]string{key}, aesKeyring...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := kr.AddAgeIdentities(ageIdentities...); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
 func init() {
 	rootCmd.AddCommand(decrypt)
 	aesKeyFlags(decrypt, "decryption")
+	aesKeyringFlag(decrypt)
+	archiveOutputFlag(decrypt)
+	decrypt.Flags().BoolVar(&decryptStdout, "stdout", false,
+		"print the decrypted resources to stdout instead of changing the files")
+	decrypt.Flags().StringVar(&decryptOutputDir, "output-dir", "",
+		"write the decrypted files into this directory, mirroring the input tree, instead of changing the files")
+	decrypt.Flags().BoolVar(&decryptVerify, "verify", false,
+		"only verify that every encrypted value can be decrypted, without changing the files")
 }
 
 func aesKeyFlags(cmd *cobra.Command, mode string) {
-	cmd.PersistentFlags().StringVar(&aesKey, "aes-key", "", fmt.Sprintf("the %s key", mode))
+	cmd.PersistentFlags().StringVar(&aesKey, "aes-key", "",
+		fmt.Sprintf("the %s key: raw, 'base64:<key>', 'hex:<key>' or a passphrase 'scrypt:<pw>' / 'argon2id:<pw>'", mode))
+	cmd.PersistentFlags().StringVar(&aesKeyFile, "aes-key-file", "", fmt.Sprintf("a file containing the %s key", mode))
 	cmd.PersistentFlags().
 		StringVar(&aesKeySecretNamespace, "aes-key-secret-namespace", "", fmt.Sprintf("the namespace of the %s key secret", mode))
 	cmd.PersistentFlags().
@@ -79,3 +130,15 @@ func aesKeyFlags(cmd *cobra.Command, mode string) {
 	cmd.PersistentFlags().
 		StringVar(&aesKeySecretKey, "aes-key-secret-key", "", fmt.Sprintf("the key of the %s key secret", mode))
 }
+
+func aesKeyringFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringSliceVar(&aesKeyring, "aes-keyring", nil,
+		"additional decryption keys, e.g. keys used by previous exports")
+	cmd.PersistentFlags().StringSliceVar(&ageIdentities, "age-identity", nil,
+		"age identity files with the private keys to decrypt values encrypted for age recipients")
+}
+
+func archiveOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&archiveOutput, "archive-output", "",
+		"the path of the new archive when a tar.gz archive is processed (default: replace the input archive)")
+}
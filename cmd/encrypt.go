@@ -2,33 +2,54 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
-	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/bakito/kubexporter/internal/types"
 )
 
 // encrypt.
 var (
+	encryptCheck bool
+
 	encrypt = &cobra.Command{
-		Use:   "encrypt <file-path(s)>",
+		Use:   "encrypt <file-directory-or-archive-path(s)>",
 		Short: "Encrypt secrets in exported resource files",
+		Long: "Encrypt the fields of 'encrypted.kindFields' and 'encrypted.recipientKindFields' of the config " +
+			"in exported resource files. Without encrypted fields in the config, Secret data and stringData are encrypted.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			key, err := evaluateAesKey(cmd)
+			config, err := loadConfig(cmd, configFlags, printFlags)
 			if err != nil {
 				return err
 			}
+			enc := config.Encrypted
+			if len(enc.KindFields) == 0 && len(enc.RecipientKindFields) == 0 {
+				enc.KindFields = types.DefaultEncryptedKindFields
+			}
 
-			printFlags = &genericclioptions.PrintFlags{
-				OutputFormat:       new(types.DefaultFormat),
-				JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
+			if !encryptCheck {
+				if usesAesKeyFlags() || (enc.AesKey == "" && enc.AesKeyFile == "" && len(enc.KindFields) > 0) {
+					if enc.AesKey, err = evaluateAesKey(cmd); err != nil {
+						return err
+					}
+				}
+				if err := enc.Setup(); err != nil {
+					return err
+				}
 			}
 
-			return types.Encrypt(printFlags, key, args...)
+			// masked fields are not encrypted by the export
+			enc.KindFields = config.Masked.KindFields.Diff(enc.KindFields)
+			enc.RecipientKindFields = config.Masked.KindFields.Diff(enc.RecipientKindFields)
+
+			return types.Encrypt(config, encryptCheck, archiveOutput, args...)
 		},
 	}
 )
 
 func init() {
 	rootCmd.AddCommand(encrypt)
+	printFlags.AddFlags(encrypt)
 	aesKeyFlags(encrypt, "encryption")
+	archiveOutputFlag(encrypt)
+	encrypt.Flags().BoolVar(&encryptCheck, "check", false,
+		"do not change the files, but fail if a field to encrypt contains plaintext")
 }
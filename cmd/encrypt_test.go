@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -82,3 +83,36 @@ stringData:
 		})
 	}
 }
+
+func TestEncryptCommand_check(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "Secret.test-secret.yaml")
+	testContent := `apiVersion: v1
+kind: Secret
+metadata:
+  name: test-secret
+  namespace: default
+stringData:
+  token: "my-secret-token"`
+	if err := os.WriteFile(testFile, []byte(testContent), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	t.Cleanup(func() { encryptCheck = false })
+
+	run := func(args ...string) error {
+		cmd := &cobra.Command{}
+		cmd.AddCommand(encrypt)
+		cmd.SetArgs(append([]string{"encrypt"}, args...))
+		return cmd.Execute()
+	}
+
+	if err := run(dir, "--check"); err == nil {
+		t.Error("expected check to fail on plaintext")
+	}
+	if err := run(dir, "--check=false", "--aes-key", "1234567890123456"); err != nil {
+		t.Errorf("unexpected error during encrypt: %v", err)
+	}
+	if err := run(dir, "--check"); err != nil {
+		t.Errorf("expected check to pass after encryption: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+// envNewAesKey env variable of the new key for rekey.
+const envNewAesKey = "KUBEXPORTER_NEW_AES_KEY"
+
+// rekey.
+var (
+	newAesKey        string
+	newAgeRecipients []string
+
+	rekey = &cobra.Command{
+		Use:   "rekey <file-or-archive-path(s)>",
+		Short: "Re-encrypt encrypted values in exported resource files with a new key or for age recipients",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kr, err := evaluateKeyring(cmd)
+			if err != nil {
+				return err
+			}
+
+			to := types.RekeyTarget{AesKey: newAesKey, Recipients: newAgeRecipients}
+			if k, ok := os.LookupEnv(envNewAesKey); ok {
+				to.AesKey = k
+			}
+			if to.AesKey == "" && len(to.Recipients) == 0 {
+				return errors.New("the new key must be provided with --new-aes-key, env variable " + envNewAesKey +
+					" or --new-age-recipient")
+			}
+
+			config, err := loadConfig(cmd, configFlags, printFlags)
+			if err != nil {
+				return err
+			}
+
+			return types.Rekey(config.PrintFlags, kr, to, archiveOutput, args...)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(rekey)
+	printFlags.AddFlags(rekey)
+	aesKeyFlags(rekey, "current")
+	aesKeyringFlag(rekey)
+	rekey.PersistentFlags().StringVar(&newAesKey, "new-aes-key", "", "the new encryption key")
+	rekey.PersistentFlags().StringSliceVar(&newAgeRecipients, "new-age-recipient", nil,
+		"the age public keys to encrypt the values for instead of a new encryption key")
+	archiveOutputFlag(rekey)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRekeyCommand(t *testing.T) {
+	testFile := "temp-secret-rekey.yaml"
+	testContent := `apiVersion: v1
+kind: Secret
+metadata:
+  name: test-secret
+  namespace: default
+type: Opaque
+data:
+  username: KUBEXPORTER_AES@f6MMcnyTt4Tm4zGotyhAzPLjWSeV42ke8hu93AJG251W4Ew17RI6pA==
+  password: KUBEXPORTER_AES@f6MMcnyTt4Tm4zGosDdI2vK9CDmU2W0eNvcVMbvee0S/vlFO6+Vf+w==`
+
+	err := os.WriteFile(testFile, []byte(testContent), 0o644)
+	if err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	defer os.Remove(testFile)
+
+	cmd := &cobra.Command{}
+	cmd.AddCommand(rekey)
+	cmd.SetArgs([]string{
+		"rekey", testFile,
+		"--aes-key", "1234567890123456",
+		"--new-aes-key", "abcdefghijklmnopqrstuvwxyz012345",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error during rekey: %v", err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if strings.Count(string(content), "KUBEXPORTER_AES@v3:") != 2 {
+		t.Errorf("expected all values to be re-encrypted, got %s", content)
+	}
+
+	cmd = &cobra.Command{}
+	cmd.AddCommand(decrypt)
+	cmd.SetArgs([]string{"decrypt", testFile, "--aes-key", "abcdefghijklmnopqrstuvwxyz012345"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("unexpected error during decrypt: %v", err)
+	}
+	content, err = os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	for _, s := range []string{"dXNlcm5hbWU=", "cGFzc3dvcmQ="} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("expected content to contain %q", s)
+		}
+	}
+}
+
+func TestRekeyCommand_multipleObjects(t *testing.T) {
+	const (
+		username = "KUBEXPORTER_AES@f6MMcnyTt4Tm4zGotyhAzPLjWSeV42ke8hu93AJG251W4Ew17RI6pA=="
+		password = "KUBEXPORTER_AES@f6MMcnyTt4Tm4zGosDdI2vK9CDmU2W0eNvcVMbvee0S/vlFO6+Vf+w=="
+	)
+	tests := []struct {
+		name    string
+		content string
+		names   []string
+	}{
+		{
+			name: "multiple documents",
+			content: `apiVersion: v1
+kind: Secret
+metadata:
+  name: first
+data:
+  username: ` + username + `
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: second
+data:
+  password: ` + password,
+			names: []string{"first", "second"},
+		},
+		{
+			name: "list",
+			content: `apiVersion: v1
+kind: List
+items:
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: first
+    data:
+      username: ` + username + `
+  - apiVersion: v1
+    kind: Secret
+    metadata:
+      name: second
+    data:
+      password: ` + password,
+			names: []string{"first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "secrets.yaml")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			cmd := &cobra.Command{}
+			cmd.AddCommand(rekey)
+			cmd.SetArgs([]string{
+				"rekey", testFile,
+				"--aes-key", "1234567890123456",
+				"--new-aes-key", "abcdefghijklmnopqrstuvwxyz012345",
+			})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("unexpected error during rekey: %v", err)
+			}
+
+			content, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatalf("failed to read test file: %v", err)
+			}
+			if strings.Count(string(content), "KUBEXPORTER_AES@v3:") != 2 {
+				t.Errorf("expected all values to be re-encrypted, got %s", content)
+			}
+			for _, name := range tt.names {
+				if !strings.Contains(string(content), "name: "+name) {
+					t.Errorf("expected secret %q to be kept, got %s", name, content)
+				}
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/bakito/kubexporter/internal/export"
+	"github.com/bakito/kubexporter/internal/secret"
 	"github.com/bakito/kubexporter/internal/types"
 	"github.com/bakito/kubexporter/version"
 )
@@ -24,7 +25,11 @@ import (
 var (
 	cfgFile     string
 	configFlags *genericclioptions.ConfigFlags
-	printFlags  *genericclioptions.PrintFlags
+	// printFlags are shared by all commands writing files, subcommands may be initialized before root.
+	printFlags = &genericclioptions.PrintFlags{
+		OutputFormat:       new(types.DefaultFormat),
+		JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
+	}
 )
 
 // rootCmd represents the base command when called without any subcommands.
@@ -51,6 +56,55 @@ func readConfig(
 	cmd *cobra.Command,
 	configFlags *genericclioptions.ConfigFlags,
 	printFlags *genericclioptions.PrintFlags,
+) (*types.Config, error) {
+	config, err := loadConfig(cmd, configFlags, printFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Masked.UsesHmacKeySecret() {
+		ref := config.Masked.HmacKeySecret
+		key, err := secret.ReadKey(cmd.Context(), config, ref.Namespace, ref.Name, ref.Key)
+		if err != nil {
+			return nil, fmt.Errorf("read hmac key: %w", err)
+		}
+		config.Masked.HmacKey = key
+	}
+
+	if err := config.Masked.Setup(); err != nil {
+		return nil, err
+	}
+
+	if err := config.Encrypted.Setup(); err != nil {
+		return nil, err
+	}
+
+	config.Encrypted.KindFields = config.Masked.KindFields.Diff(config.Encrypted.KindFields)
+	config.Encrypted.RecipientKindFields = config.Masked.KindFields.Diff(config.Encrypted.RecipientKindFields)
+
+	if config.SealedSecrets.FetchesCert() {
+		ss := config.SealedSecrets
+		cert, err := secret.FetchSealingCert(cmd.Context(), config, ss.ControllerNamespace, ss.ControllerName)
+		if err != nil {
+			return nil, err
+		}
+		ss.Cert = cert
+	}
+
+	if err := config.SealedSecrets.Setup(); err != nil {
+		return nil, err
+	}
+
+	correctProgressForNonTerminalRun(config)
+
+	return config, nil
+}
+
+// loadConfig loads the config file and applies the flags, without setting up masking and encryption.
+func loadConfig(
+	cmd *cobra.Command,
+	configFlags *genericclioptions.ConfigFlags,
+	printFlags *genericclioptions.PrintFlags,
 ) (*types.Config, error) {
 	config := types.NewConfig(configFlags, printFlags)
 
@@ -65,9 +119,24 @@ func readConfig(
 		case "namespace":
 			namespaces, _ := cmd.Flags().GetStringSlice(f.Name)
 			config.Namespaces = namespaces
+		case "namespace-selector":
+			config.NamespaceSelector = f.Value.String()
+		case "exclude-namespaces":
+			sl, _ := cmd.Flags().GetStringSlice(f.Name)
+			config.Excluded.Namespaces = sl
 		case "include-cluster-resources":
 			b, _ := cmd.Flags().GetBool(f.Name)
 			config.IncludeClusterResources = b
+		case "namespace-mapping":
+			m, _ := cmd.Flags().GetStringToString(f.Name)
+			config.NamespaceMapping = m
+		case "owner-graph":
+			sl, _ := cmd.Flags().GetStringSlice(f.Name)
+			config.OwnerReferences.Graph = sl
+		case "selector":
+			config.Selector.LabelSelector = f.Value.String()
+		case "field-selector":
+			config.Selector.FieldSelector = f.Value.String()
 		case "target":
 			config.Target = f.Value.String()
 		case "worker":
@@ -90,6 +159,12 @@ func readConfig(
 			config.PrintSize = b
 		case "progress":
 			config.Progress = types.Progress(f.Value.String())
+		case "strip-defaults":
+			sl, _ := cmd.Flags().GetStringSlice(f.Name)
+			config.StripDefaults = sl
+		case "neat":
+			b, _ := cmd.Flags().GetBool(f.Name)
+			config.Neat = b
 		case "lists":
 			b, _ := cmd.Flags().GetBool(f.Name)
 			config.AsLists = b
@@ -119,18 +194,6 @@ func readConfig(
 		}
 	})
 
-	if err := config.Masked.Setup(); err != nil {
-		return nil, err
-	}
-
-	if err := config.Encrypted.Setup(); err != nil {
-		return nil, err
-	}
-
-	config.Encrypted.KindFields = config.Masked.KindFields.Diff(config.Encrypted.KindFields)
-
-	correctProgressForNonTerminalRun(config)
-
 	return config, nil
 }
 
@@ -174,21 +237,25 @@ func init() {
 	rootCmd.Flags().BoolP(cflagP("archive", "a", false))
 	rootCmd.Flags().StringP(cflagP("progress", "p", string(types.ProgressBar)))
 	rootCmd.Flags().BoolP(cflagP("lists", "l", false))
+	rootCmd.Flags().Bool(cflag("neat", false))
+	rootCmd.Flags().StringSlice(cflag("strip-defaults", []string{}))
 	rootCmd.Flags().StringSliceP(cflagP("include-kinds", "i", []string{}))
 	rootCmd.Flags().StringSliceP(cflagP("exclude-kinds", "e", []string{}))
 	rootCmd.Flags().Duration(cflag[time.Duration]("created-within", 0))
 	rootCmd.Flags().StringSliceP(cflagP("namespace", "n", []string{}))
+	rootCmd.Flags().String(cflag("namespace-selector", ""))
+	rootCmd.Flags().StringSlice(cflag("exclude-namespaces", []string{}))
 	rootCmd.Flags().Bool(cflag("include-cluster-resources", false))
+	rootCmd.Flags().StringToString(cflag("namespace-mapping", map[string]string{}))
+	rootCmd.Flags().StringSlice(cflag("owner-graph", []string{}))
+	rootCmd.Flags().String(cflag("selector", ""))
+	rootCmd.Flags().String(cflag("field-selector", ""))
 
 	configFlags = genericclioptions.NewConfigFlags(true)
 	configFlags.Namespace = nil
 	configFlags.CacheDir = nil
 	configFlags.AddFlags(rootCmd.Flags())
 
-	printFlags = &genericclioptions.PrintFlags{
-		OutputFormat:       new(types.DefaultFormat),
-		JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
-	}
 	printFlags.AddFlags(rootCmd)
 
 	// silence klog log output
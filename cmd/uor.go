@@ -7,23 +7,40 @@ import (
 )
 
 // updateOwnerReferences.
-var updateOwnerReferences = &cobra.Command{
-	Use:     "update-owner-references",
-	Aliases: []string{"uor"},
-	Short:   "Update owner references of an export against the current cluster",
-	RunE: func(cmd *cobra.Command, _ []string) error {
-		config, err := readConfig(cmd, configFlags, printFlags)
-		if err != nil {
-			return err
-		}
+var (
+	uorDryRun bool
+	uorReport string
 
-		return uor.Update(cmd.Context(), config)
-	},
-}
+	updateOwnerReferences = &cobra.Command{
+		Use:     "update-owner-references",
+		Aliases: []string{"uor"},
+		Short:   "Update owner references of an export against the current cluster",
+		Long: "Update owner references of an export against the current cluster.\n" +
+			"The export is read from the target directory or from a tar.gz archive if the target points to one.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := readConfig(cmd, configFlags, printFlags)
+			if err != nil {
+				return err
+			}
+
+			return uor.Update(cmd.Context(), config, uor.Options{
+				ArchiveOut: archiveOutput,
+				DryRun:     uorDryRun,
+				Output:     uorReport,
+			})
+		},
+	}
+)
 
 func init() {
 	rootCmd.AddCommand(updateOwnerReferences)
 	configFlags.AddFlags(updateOwnerReferences.Flags())
 	printFlags.AddFlags(updateOwnerReferences)
 	updateOwnerReferences.PersistentFlags().StringVar(&cfgFile, "config", "", "config file")
+	updateOwnerReferences.Flags().StringP(cflagP("target", "t", "exports"))
+	updateOwnerReferences.Flags().IntP(cflagP("worker", "w", 1))
+	updateOwnerReferences.Flags().BoolVar(&uorDryRun, "dry-run", false, "print the changes without updating the files")
+	updateOwnerReferences.Flags().
+		StringVar(&uorReport, "report", uor.OutputTable, "the report format of the changes table|json")
+	archiveOutputFlag(updateOwnerReferences)
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/bakito/kubexporter/internal/uor"
+)
+
+// rewriteReferences.
+var (
+	rewriteMappingFile string
+	rewriteOffline     bool
+
+	rewriteReferences = &cobra.Command{
+		Use:   "rewrite-references",
+		Short: "Rewrite cluster-specific references of an export for restoring into another cluster",
+		Long: "Rewrite cluster-specific references of an export for restoring into another cluster.\n" +
+			"Namespaces, names, uids and storage classes are mapped with the mapping file. " +
+			"Owner uids, service cluster ips, claimed volumes and service account token secrets " +
+			"are resolved from the current cluster if possible.\n" +
+			"The export is read from the target directory or from a tar.gz archive if the target points to one.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := readConfig(cmd, configFlags, printFlags)
+			if err != nil {
+				return err
+			}
+
+			mapping := &uor.Mapping{}
+			if rewriteMappingFile != "" {
+				if mapping, err = uor.ReadMapping(rewriteMappingFile); err != nil {
+					return err
+				}
+			}
+
+			return uor.Rewrite(cmd.Context(), config, mapping, rewriteOffline, uor.Options{
+				ArchiveOut: archiveOutput,
+				DryRun:     uorDryRun,
+				Output:     uorReport,
+			})
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(rewriteReferences)
+	configFlags.AddFlags(rewriteReferences.Flags())
+	printFlags.AddFlags(rewriteReferences)
+	rewriteReferences.PersistentFlags().StringVar(&cfgFile, "config", "", "config file")
+	rewriteReferences.Flags().StringP(cflagP("target", "t", "exports"))
+	rewriteReferences.Flags().StringVarP(&rewriteMappingFile, "mapping", "m", "", "the reference mapping file")
+	rewriteReferences.Flags().BoolVar(&rewriteOffline, "offline", false, "do not resolve live values from the cluster")
+	rewriteReferences.Flags().BoolVar(&uorDryRun, "dry-run", false, "print the changes without updating the files")
+	rewriteReferences.Flags().
+		StringVar(&uorReport, "report", uor.OutputTable, "the report format of the changes table|json")
+	archiveOutputFlag(rewriteReferences)
+}
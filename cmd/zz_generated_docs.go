@@ -3,16 +3,24 @@ package cmd
 
 // cobra-doc-start
 var docsCobraMapping = map[string]string{
+	`exclude-namespaces`: `Namespaces to be excluded; globs and /regexes/ are supported`,
 	`exclude-kinds`: `List all kinds to be excluded`,
 	`include-kinds`: `List all kinds to be included`,
 	`created-within`: `The max allowed age duration for the resources`,
+	`owner-graph`: `Write the owner graph into the target directory dot|json`,
+	`strip-defaults`: `Kind rules to strip fields equal to the OpenAPI schema default`,
+	`neat`: `Strip server-populated fields to get re-applicable manifests`,
 	`lists`: `Export as lists instead of individual files`,
 	`target`: `The target directory`,
 	`clear-target`: `Clear the target directory before exporting`,
 	`summary`: `If enabled, a summary is printed`,
 	`progress`: `Progress mode bar|bubbles|simple|none`,
 	`namespace`: `A single namespace (default all)`,
-	`include-cluster-resources`: `Export cluster-scoped resources too, when a namespace filter is active`,
+	`namespace-selector`: `Label selector to select namespaces e.g. 'tenant=foo'`,
+	`include-cluster-resources`: `Export cluster-scoped resources too, when namespaces are selected`,
+	`selector`: `Label selector e.g. 'app=foo,tier!=db'`,
+	`field-selector`: `Field selector e.g. 'metadata.name!=foo'`,
+	`namespace-mapping`: `Map exported namespaces to other namespaces (source: target)`,
 	`worker`: `The number of parallel worker`,
 	`archive`: `Create a tar.gz archive`,
 	`otlp-metrics`: `OTLP Metrics are enabled`,
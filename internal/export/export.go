@@ -3,6 +3,7 @@ package export
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"slices"
 	"strconv"
@@ -66,6 +67,10 @@ func (e *exporter) Export(ctx context.Context) error {
 		}
 	}
 
+	if err := e.config.ResolveNamespaces(e.listNamespaces(ctx)); err != nil {
+		return err
+	}
+
 	e.writeIntro()
 
 	resources, err := e.listResources()
@@ -97,6 +102,9 @@ func (e *exporter) Export(ctx context.Context) error {
 		prog = nop.NewProgress()
 	}
 
+	e.config.OwnerGraph().Lookup = e.lookupOwner(ctx)
+	e.config.SchemaDefaults().Lookup = e.lookupSchema()
+
 	var workers []worker.Worker
 	for i := range e.config.Worker {
 		workers = append(workers, worker.New(i, e.config, e.ac, prog))
@@ -130,10 +138,31 @@ func (e *exporter) Export(ctx context.Context) error {
 		return exportErr
 	}
 
+	if len(e.config.OwnerReferences.Graph) > 0 {
+		if err := e.writeOwnerGraph(); err != nil {
+			return err
+		}
+	}
+
 	if e.config.Summary {
 		if err := e.printSummary(resources); err != nil {
 			return err
 		}
+		if err := e.printExcludedBy(resources); err != nil {
+			return err
+		}
+		if err := e.printTransformed(resources); err != nil {
+			return err
+		}
+	}
+
+	if !e.config.Quiet {
+		if err := e.printUnmappedNamespaceReferences(resources); err != nil {
+			return err
+		}
+		if err := e.printMaskedValues(resources); err != nil {
+			return err
+		}
 	}
 
 	if e.config.Metrics != nil && e.config.Metrics.OTLP.Enabled {
@@ -182,10 +211,45 @@ func (e *exporter) writeIntro() {
 		e.l.Printf("  all namespaces 🏘️\n")
 	} else {
 		e.l.Printf("  namespaces %s 🏠\n", strings.Join(e.config.Namespaces, ", "))
-		if e.config.IncludeClusterResources {
+		if e.config.NamespaceSelector != "" {
+			e.l.Printf("  namespace selector 🔎 %s\n", e.config.NamespaceSelector)
+		}
+		if len(e.config.Excluded.Namespaces) > 0 {
+			e.l.Printf("  excluded namespaces 🚫 %s\n", strings.Join(e.config.Excluded.Namespaces, ", "))
+		}
+		if e.config.SelectsNamespaces() && e.config.IncludeClusterResources {
 			e.l.Printf("  include cluster resources 🌐\n")
 		}
 	}
+	if !e.config.Selector.IsEmpty() {
+		e.l.Printf("  selector 🔎 %s\n", e.config.Selector)
+	}
+	for kind, s := range e.config.KindSelectors {
+		if !s.IsEmpty() {
+			e.l.Printf("  selector %s 🔎 %s\n", kind, s)
+		}
+	}
+	if e.config.Excluded.OptOutKey != "" {
+		e.l.Printf("  opt-out key 🙈 %s\n", e.config.Excluded.OptOutKey)
+	}
+	if e.config.Included.OptInKey != "" {
+		e.l.Printf("  opt-in key 🙋 %s\n", e.config.Included.OptInKey)
+	}
+	if e.config.Neat {
+		e.l.Printf("  neat 🧹\n")
+	}
+	if len(e.config.StripDefaults) > 0 {
+		e.l.Printf("  strip schema defaults 🧹 %s\n", strings.Join(e.config.StripDefaults, ", "))
+	}
+	if e.config.HasTransform() {
+		e.l.Printf("  transform ✏️ %s\n", strings.Join(slices.Sorted(maps.Keys(e.config.Transform)), ", "))
+	}
+	for _, p := range e.config.Plugins {
+		e.l.Printf("  plugin 🔌 %s\n", p)
+	}
+	if len(e.config.NamespaceMapping) > 0 {
+		e.l.Printf("  namespace mapping 🔀 %s\n", e.config.NamespaceMapping)
+	}
 	e.l.Printf("  target %q 📁\n", e.config.Target)
 	e.l.Printf("  format %q 📜\n", e.config.OutputFormat())
 	if e.config.Worker > 1 {
@@ -201,13 +265,34 @@ func (e *exporter) writeIntro() {
 	if e.config.ConsiderOwnerReferences {
 		e.l.Printf("  considering owner references 👑\n")
 	}
+	if e.config.OwnerReferences.Transitive {
+		e.l.Printf("  following owner chains 🔗\n")
+	}
+	if e.config.OwnerReferences.ControllerOnly {
+		e.l.Printf("  controller owners only 🎛️\n")
+	}
+	if len(e.config.OwnerReferences.ExcludeOwnedBy) > 0 {
+		e.l.Printf("  exclude owned by %s 👑\n", strings.Join(e.config.OwnerReferences.ExcludeOwnedBy, ", "))
+	}
+	if len(e.config.OwnerReferences.Graph) > 0 {
+		e.l.Printf("  owner graph %s 🕸️\n", strings.Join(e.config.OwnerReferences.Graph, ", "))
+	}
 
 	if len(e.config.Masked.KindFields) > 0 {
 		e.l.Printf("  masked fields 🤿 %v\n", e.config.Masked.KindFields)
 	}
+	if e.config.Masked.HasPatterns() {
+		e.l.Printf("  masked value patterns 🤿 %s\n", strings.Join(e.config.Masked.PatternNames(), ", "))
+	}
 	if len(e.config.Encrypted.KindFields) > 0 {
 		e.l.Printf("  encrypted fields 🔒 %v\n", e.config.Encrypted.KindFields)
 	}
+	if len(e.config.Encrypted.RecipientKindFields) > 0 {
+		e.l.Printf("  recipient encrypted fields 🔐 %v\n", e.config.Encrypted.RecipientKindFields)
+	}
+	if e.config.SealedSecrets.Enabled {
+		e.l.Printf("  secrets as %s sealed secrets 🦭\n", e.config.SealedSecrets.Scope)
+	}
 	if e.config.CreatedWithin > 0 {
 		e.l.Printf("  created within %s ⏱️\n", e.config.CreatedWithin.String())
 	}
@@ -260,9 +345,12 @@ func (e *exporter) listResources() ([]*types.GroupResource, error) {
 				APIGroupVersion: gv.String(),
 				APIResource:     resource,
 			}
+			r.Selector = e.config.SelectorFor(r)
 			if !allowsList(resource) ||
-				e.config.IsExcluded(r) ||
-				(!resource.Namespaced && e.config.HasNamespaces() && !e.config.IncludeClusterResources) {
+				(!resource.Namespaced && e.config.SelectsNamespaces() && !e.config.IncludeClusterResources) {
+				continue
+			}
+			if e.config.IsExcluded(r) && !e.config.OptIn(r) {
 				continue
 			}
 
@@ -272,12 +360,30 @@ func (e *exporter) listResources() ([]*types.GroupResource, error) {
 	return resources, nil
 }
 
+// listNamespaces lists the names of the namespaces matching the label selector.
+func (e *exporter) listNamespaces(ctx context.Context) types.NamespaceLister {
+	return func(labelSelector string) ([]string, error) {
+		gvr := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+		ul, err := e.ac.Client.Resource(gvr).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(ul.Items))
+		for _, ns := range ul.Items {
+			names = append(names, ns.GetName())
+		}
+		return names, nil
+	}
+}
+
 func allowsList(r metav1.APIResource) bool {
 	return slices.Contains(r.Verbs, "list")
 }
 
 func (e *exporter) printSummary(resources []*types.GroupResource) error {
 	withPages := e.config.QueryPageSize > 0
+	withSelector := e.config.HasSelectors()
+	withStripped := len(e.config.StripDefaults) > 0
 
 	table := render.Table()
 	header := []string{
@@ -285,8 +391,16 @@ func (e *exporter) printSummary(resources []*types.GroupResource) error {
 		"Version",
 		"Kind",
 		"Namespaced",
+	}
+	if withSelector {
+		header = append(header, "Selector")
+	}
+	header = append(header,
 		"Total Instances",
 		"Exported Instances",
+	)
+	if withStripped {
+		header = append(header, "Stripped Fields")
 	}
 	if e.config.PrintSize {
 		header = append(header, "Exported Size")
@@ -306,16 +420,24 @@ func (e *exporter) printSummary(resources []*types.GroupResource) error {
 	var inst int
 	var size int64
 	var totalInst int
+	var stripped int
 	var pages int
 
 	for _, r := range resources {
-		if err := table.Append(r.Report(e.config.PrintSize, e.config.Verbose && e.stats.HasErrors(), withPages)); err != nil {
+		if err := table.Append(r.Report(
+			e.config.PrintSize,
+			e.config.Verbose && e.stats.HasErrors(),
+			withPages,
+			withSelector,
+			withStripped,
+		)); err != nil {
 			return err
 		}
 		qd = qd.Add(r.QueryDuration)
 		ed = ed.Add(r.ExportDuration)
 		totalInst += r.Instances
 		inst += r.ExportedInstances
+		stripped += r.StrippedFields
 		size += r.ExportedSize
 		pages += r.Pages
 	}
@@ -328,8 +450,16 @@ func (e *exporter) printSummary(resources []*types.GroupResource) error {
 		"",
 		"",
 		"",
+	}
+	if withSelector {
+		totalRow = append(totalRow, "")
+	}
+	totalRow = append(totalRow,
 		strconv.Itoa(totalInst),
 		strconv.Itoa(inst),
+	)
+	if withStripped {
+		totalRow = append(totalRow, strconv.Itoa(stripped))
 	}
 	if e.config.PrintSize {
 		totalRow = append(totalRow, humanize.Bytes(uint64(size)))
@@ -345,6 +475,100 @@ func (e *exporter) printSummary(resources []*types.GroupResource) error {
 	return table.Render()
 }
 
+func (e *exporter) printExcludedBy(resources []*types.GroupResource) error {
+	var rows [][]string
+	for _, r := range resources {
+		rules := make([]string, 0, len(r.ExcludedBy))
+		for rule := range r.ExcludedBy {
+			rules = append(rules, rule)
+		}
+		slices.Sort(rules)
+		for _, rule := range rules {
+			rows = append(rows, []string{r.GroupKind(), rule, strconv.Itoa(r.ExcludedBy[rule])})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	e.l.Printf("\n  🚫 excluded instances\n")
+	table := render.Table()
+	table.Header("Kind", "Rule", "Instances")
+	for _, row := range rows {
+		if err := table.Append(row); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+func (e *exporter) printTransformed(resources []*types.GroupResource) error {
+	var rows [][]string
+	for _, r := range resources {
+		ops := make([]string, 0, len(r.Transformed))
+		for op := range r.Transformed {
+			ops = append(ops, op)
+		}
+		slices.Sort(ops)
+		for _, op := range ops {
+			rows = append(rows, []string{r.GroupKind(), op, strconv.Itoa(r.Transformed[op])})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	e.l.Printf("\n  ✏️ transformed fields\n")
+	table := render.Table()
+	table.Header("Kind", "Operation", "Changes")
+	for _, row := range rows {
+		if err := table.Append(row); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+func (e *exporter) printMaskedValues(resources []*types.GroupResource) error {
+	var values []types.MaskedValue
+	for _, r := range resources {
+		values = append(values, r.MaskedValues...)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	e.l.Printf("\n  🤿 values masked by pattern\n")
+	table := render.Table()
+	table.Header("Namespace", "Kind", "Name", "Field", "Pattern")
+	for _, v := range values {
+		if err := table.Append(v.Report()); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+func (e *exporter) printUnmappedNamespaceReferences(resources []*types.GroupResource) error {
+	var refs []types.NamespaceReference
+	for _, r := range resources {
+		refs = append(refs, r.UnmappedNamespaceReferences...)
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	e.l.Printf("\n  ⚠️ namespace references that could not be mapped\n")
+	table := render.Table()
+	table.Header("Namespace", "Kind", "Name", "Field", "Value", "Reason")
+	for _, ref := range refs {
+		if err := table.Append(ref.Report()); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
 func (e *exporter) printStats() {
 	fmt.Println()
 	if e.archive != "" {
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+// lookupOwner fetches owners from the cluster, that are not part of the export.
+func (e *exporter) lookupOwner(ctx context.Context) types.OwnerLookup {
+	return func(ref metav1.OwnerReference, namespace string) (*unstructured.Unstructured, error) {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return nil, err
+		}
+		mapping, err := e.ac.Mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: ref.Kind}, gv.Version)
+		if err != nil {
+			return nil, err
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			return e.ac.Client.Resource(mapping.Resource).Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		}
+		return e.ac.Client.Resource(mapping.Resource).Get(ctx, ref.Name, metav1.GetOptions{})
+	}
+}
+
+func (e *exporter) writeOwnerGraph() error {
+	graph := e.config.OwnerGraph()
+	for _, format := range e.config.OwnerReferences.Graph {
+		name := filepath.Join(e.config.Target, types.OwnerGraphFileName+"."+format)
+		if err := os.MkdirAll(filepath.Dir(name), os.ModePerm); err != nil {
+			return err
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+
+		if format == types.OwnerGraphDOT {
+			err = graph.WriteDOT(f)
+		} else {
+			err = graph.WriteJSON(f)
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		e.l.Checkf("🕸️\tOwner graph %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/openapi"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+// lookupSchema fetches the OpenAPI v3 documents from discovery, the documents are cached per group version.
+// Each group version is fetched once, concurrent lookups of other group versions are not blocked.
+func (e *exporter) lookupSchema() types.SchemaLookup {
+	type entry struct {
+		once sync.Once
+		doc  *types.OpenAPIDocument
+		err  error
+	}
+	var mu sync.Mutex
+	entries := make(map[string]*entry)
+	paths := sync.OnceValues(func() (map[string]openapi.GroupVersion, error) {
+		return e.ac.DiscoveryClient.OpenAPIV3().Paths()
+	})
+
+	return func(res *types.GroupResource) (*types.OpenAPIDocument, error) {
+		path := "apis/" + res.APIGroupVersion
+		if res.APIGroup == "" {
+			path = "api/" + res.APIVersion
+		}
+
+		mu.Lock()
+		en, ok := entries[path]
+		if !ok {
+			en = &entry{}
+			entries[path] = en
+		}
+		mu.Unlock()
+
+		en.once.Do(func() {
+			en.doc, en.err = fetchSchema(paths, path)
+		})
+		return en.doc, en.err
+	}
+}
+
+// fetchSchema fetches the OpenAPI v3 document of the discovery path.
+func fetchSchema(paths func() (map[string]openapi.GroupVersion, error), path string) (*types.OpenAPIDocument, error) {
+	p, err := paths()
+	if err != nil {
+		return nil, err
+	}
+	gv, ok := p[path]
+	if !ok {
+		return nil, fmt.Errorf("no OpenAPI v3 schema published for %s", path)
+	}
+	b, err := gv.Schema(runtime.ContentTypeJSON)
+	if err != nil {
+		return nil, err
+	}
+	doc := &types.OpenAPIDocument{}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
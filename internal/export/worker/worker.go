@@ -18,6 +18,7 @@ import (
 
 	"github.com/bakito/kubexporter/internal/client"
 	"github.com/bakito/kubexporter/internal/export/progress"
+	"github.com/bakito/kubexporter/internal/plugin"
 	"github.com/bakito/kubexporter/internal/types"
 	"github.com/bakito/kubexporter/internal/utils"
 )
@@ -111,6 +112,7 @@ func (w *worker) list(
 	group, version, kind string,
 	namespace string,
 	continueValue string,
+	selector types.Selector,
 ) (*unstructured.UnstructuredList, error) {
 	mapping, err := w.ac.Mapper.RESTMapping(schema.GroupKind{Group: group, Kind: kind}, version)
 	if err != nil {
@@ -125,7 +127,11 @@ func (w *worker) list(
 		// for cluster-wide resources
 		dr = w.ac.Client.Resource(mapping.Resource)
 	}
-	opts := metav1.ListOptions{Continue: continueValue}
+	opts := metav1.ListOptions{
+		Continue:      continueValue,
+		LabelSelector: selector.LabelSelector,
+		FieldSelector: selector.FieldSelector,
+	}
 	if !w.config.AsLists {
 		// for lists, we do no pagination
 		opts.Limit = int64(w.config.QueryPageSize)
@@ -191,7 +197,7 @@ func (w *worker) listResources(
 		},
 	)
 	start := time.Now()
-	ul, err := w.list(ctx, res.APIGroup, res.APIVersion, res.APIResource.Kind, namespace, hasMorePages)
+	ul, err := w.list(ctx, res.APIGroup, res.APIVersion, res.APIResource.Kind, namespace, hasMorePages, res.Selector)
 
 	if w.prog != nil {
 		w.prog.IncrementResourceBarBy(w.id, 1)
@@ -225,9 +231,9 @@ func (w *worker) listResources(
 	var instances int
 	var exportedSize int64
 	if w.config.AsLists {
-		instances, exportedSize = w.exportLists(res, ul)
+		instances, exportedSize = w.exportLists(ctx, res, ul)
 	} else {
-		instances, exportedSize = w.exportSingleResources(res, ul)
+		instances, exportedSize = w.exportSingleResources(ctx, res, ul)
 	}
 	res.ExportedInstances += instances
 	res.ExportedSize += exportedSize
@@ -240,7 +246,50 @@ func (w *worker) listResources(
 	return ul.GetContinue()
 }
 
-func (w *worker) exportLists(res *types.GroupResource, ul *unstructured.UnstructuredList) (int, int64) {
+// prepare filters and transforms the listed instances and passes them to the plugins.
+// Excluded instances and instances dropped by a plugin are not returned.
+func (w *worker) prepare(ctx context.Context, res *types.GroupResource, ul *unstructured.UnstructuredList) []unstructured.Unstructured {
+	var items []unstructured.Unstructured
+	for _, u := range ul.Items {
+		if w.config.IsInstanceExcluded(res, u) {
+			continue
+		}
+		w.config.FilterFields(res, u)
+		w.config.NeatFields(res, u)
+		w.config.StripDefaultFields(res, u)
+		w.config.TransformFields(res, u)
+		items = append(items, u)
+	}
+	for _, p := range w.config.PluginsFor(res) {
+		var err error
+		if items, err = plugin.Run(ctx, p, items); err != nil {
+			w.stats.Errors++
+			res.Error = err.Error()
+		}
+	}
+	return items
+}
+
+// process masks, encrypts and maps the namespaces of the instance. Instances of kinds with an output conversion
+// are converted after the namespace mapping instead, so their fields are neither masked nor encrypted.
+func (w *worker) process(res *types.GroupResource, u unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if w.config.Converts(res) {
+		w.config.MapNamespaces(res, &u)
+		return w.config.Convert(res, &u)
+	}
+	w.config.MaskFields(res, u)
+	w.config.EncryptFields(res, u)
+	w.config.MaskValues(res, u)
+	w.config.SortSliceFields(res, u)
+	w.config.MapNamespaces(res, &u)
+	return &u, nil
+}
+
+func (w *worker) exportLists(
+	ctx context.Context,
+	res *types.GroupResource,
+	ul *unstructured.UnstructuredList,
+) (int, int64) {
 	if res == nil || ul == nil {
 		return 0, 0
 	}
@@ -248,22 +297,26 @@ func (w *worker) exportLists(res *types.GroupResource, ul *unstructured.Unstruct
 	clone.Items = nil
 	unstructured.RemoveNestedField(clone.Object, "metadata")
 
+	if w.config.Converts(res) {
+		// the items are of another kind
+		clone.SetAPIVersion("v1")
+		clone.SetKind("List")
+	}
+
 	perNs := make(map[string]*unstructured.UnstructuredList)
-	for _, u := range ul.Items {
-		if w.config.IsInstanceExcluded(res, u) {
+	for _, u := range w.prepare(ctx, res, ul) {
+		us, err := w.process(res, u)
+		if err != nil {
+			res.Error = err.Error()
 			continue
 		}
-		w.config.FilterFields(res, u)
-		w.config.MaskFields(res, u)
-		w.config.EncryptFields(res, u)
-		w.config.SortSliceFields(res, u)
 
-		if _, ok := perNs[u.GetNamespace()]; !ok {
+		if _, ok := perNs[us.GetNamespace()]; !ok {
 			ul := &unstructured.UnstructuredList{}
 			clone.DeepCopyInto(ul)
-			perNs[u.GetNamespace()] = ul
+			perNs[us.GetNamespace()] = ul
 		}
-		perNs[u.GetNamespace()].Items = append(perNs[u.GetNamespace()].Items, u)
+		perNs[us.GetNamespace()].Items = append(perNs[us.GetNamespace()].Items, *us)
 	}
 
 	cnt := 0
@@ -281,7 +334,7 @@ func (w *worker) exportLists(res *types.GroupResource, ul *unstructured.Unstruct
 
 func (w *worker) exportOneSingleList(res *types.GroupResource, ns string, usl *unstructured.UnstructuredList) (bool, int64) {
 	w.stats.addNamespace(ns)
-	filename, err := w.config.ListFileName(res, ns)
+	filename, err := w.config.ListFileName(w.config.ConvertedResource(res), ns)
 	if err != nil {
 		res.Error = err.Error()
 		return false, 0
@@ -313,14 +366,19 @@ func (w *worker) exportOneSingleList(res *types.GroupResource, ns string, usl *u
 	return true, fi.Size()
 }
 
-func (w *worker) exportSingleResources(res *types.GroupResource, ul *unstructured.UnstructuredList) (int, int64) {
+func (w *worker) exportSingleResources(
+	ctx context.Context,
+	res *types.GroupResource,
+	ul *unstructured.UnstructuredList,
+) (int, int64) {
 	if res == nil || ul == nil {
 		return 0, 0
 	}
 	names := make(map[string]int)
 	cnt := 0
 	var exportedSize int64
-	for _, u := range ul.Items {
+	items := w.prepare(ctx, res, ul)
+	for _, u := range items {
 		ok, s := w.exportOneSingleResource(res, u, names)
 		if ok {
 			cnt++
@@ -328,6 +386,10 @@ func (w *worker) exportSingleResources(res *types.GroupResource, ul *unstructure
 		}
 		w.prog.IncrementResourceBarBy(w.id, 1)
 	}
+	// excluded and dropped instances
+	if skipped := len(ul.Items) - len(items); skipped > 0 {
+		w.prog.IncrementResourceBarBy(w.id, skipped)
+	}
 	return cnt, exportedSize
 }
 
@@ -336,50 +398,46 @@ func (w *worker) exportOneSingleResource(
 	u unstructured.Unstructured,
 	names map[string]int,
 ) (bool, int64) {
-	if !w.config.IsInstanceExcluded(res, u) {
-		w.stats.addNamespace(u.GetNamespace())
-		w.config.FilterFields(res, u)
-		w.config.MaskFields(res, u)
-		w.config.EncryptFields(res, u)
-		w.config.SortSliceFields(res, u)
-		us := &u
-
-		namespaceName := strings.ToLower(fmt.Sprintf("%s.%s", us.GetNamespace(), us.GetName()))
-		nameCnt := names[namespaceName]
+	w.stats.addNamespace(u.GetNamespace())
+	us, err := w.process(res, u)
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
+	}
 
-		filename, err := w.config.FileName(res, us, nameCnt)
-		if err != nil {
-			res.Error = err.Error()
-			return false, 0
-		}
+	namespaceName := strings.ToLower(fmt.Sprintf("%s.%s", us.GetNamespace(), us.GetName()))
+	nameCnt := names[namespaceName]
 
-		names[namespaceName] = nameCnt + 1
+	filename, err := w.config.FileName(w.config.ConvertedResource(res), us, nameCnt)
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
+	}
 
-		filename = filepath.Join(w.config.Target, filename)
-		err = os.MkdirAll(filepath.Dir(filename), os.ModePerm)
-		if err != nil {
-			res.Error = err.Error()
-			return false, 0
-		}
-		f, err := os.Create(filename)
-		if err != nil {
-			res.Error = err.Error()
-			return false, 0
-		}
-		defer f.Close()
+	names[namespaceName] = nameCnt + 1
 
-		err = utils.PrintObj(w.config.PrintFlags, us, f)
-		if err != nil {
-			res.Error = err.Error()
-			return false, 0
-		}
-		fi, err := f.Stat()
-		if err != nil {
-			res.Error = err.Error()
-			return false, 0
-		}
-		return true, fi.Size()
+	filename = filepath.Join(w.config.Target, filename)
+	err = os.MkdirAll(filepath.Dir(filename), os.ModePerm)
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
 	}
+	f, err := os.Create(filename)
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
+	}
+	defer f.Close()
 
-	return false, 0
+	err = utils.PrintObj(w.config.PrintFlags, us, f)
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		res.Error = err.Error()
+		return false, 0
+	}
+	return true, fi.Size()
 }
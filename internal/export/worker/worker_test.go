@@ -1,11 +1,17 @@
 package worker
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/google/uuid"
@@ -134,7 +140,7 @@ func TestWorker_exportLists(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w, tmpDir := setupWorker(t)
-			w.exportLists(tt.res, tt.ul)
+			w.exportLists(t.Context(), tt.res, tt.ul)
 			if tt.validate != nil {
 				tt.validate(t, tmpDir)
 			}
@@ -200,7 +206,7 @@ func TestWorker_exportSingleResources(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w, tmpDir := setupWorker(t)
-			w.exportSingleResources(tt.res, tt.ul)
+			w.exportSingleResources(t.Context(), tt.res, tt.ul)
 			if tt.validate != nil {
 				tt.validate(t, tmpDir)
 			}
@@ -208,6 +214,79 @@ func TestWorker_exportSingleResources(t *testing.T) {
 	}
 }
 
+func TestWorker_sealedSecrets(t *testing.T) {
+	secretList := func() (*types.GroupResource, *unstructured.UnstructuredList) {
+		res := &types.GroupResource{
+			APIVersion:      "v1",
+			APIGroupVersion: "v1",
+			APIResource:     metav1.APIResource{Kind: "Secret", Namespaced: true},
+		}
+		ul := &unstructured.UnstructuredList{}
+		ul.SetAPIVersion("v1")
+		ul.SetKind("SecretList")
+		for _, name := range []string{"secret-1", "secret-2"} {
+			u := unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"data":       map[string]any{"key": "dmFsdWU="},
+			}}
+			u.SetNamespace("namespace-1")
+			u.SetName(name)
+			ul.Items = append(ul.Items, u)
+		}
+		return res, ul
+	}
+	setup := func(t *testing.T) (*worker, string) {
+		t.Helper()
+		w, tmpDir := setupWorker(t)
+		w.config.SealedSecrets.Enabled = true
+		w.config.SealedSecrets.Cert = sealingCert(t)
+		if err := w.config.SealedSecrets.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		return w, tmpDir
+	}
+
+	t.Run("should write single sealed secrets", func(t *testing.T) {
+		w, tmpDir := setup(t)
+		res, ul := secretList()
+		w.exportSingleResources(t.Context(), res, ul)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		files := checkDir(t, 2, tmpDir, "namespace-1")
+		if files[0].Name() != "bitnami.com.SealedSecret.secret-1.yaml" {
+			t.Errorf("expected bitnami.com.SealedSecret.secret-1.yaml, but got %s", files[0].Name())
+		}
+		u := unstructuredFrom(t, tmpDir, "namespace-1", files[0].Name())
+		if u.GetKind() != "SealedSecret" {
+			t.Errorf("expected SealedSecret, but got %s", u.GetKind())
+		}
+		if _, ok := u.Object["data"]; ok {
+			t.Error("expected no plaintext data")
+		}
+	})
+
+	t.Run("should write a list of sealed secrets", func(t *testing.T) {
+		w, tmpDir := setup(t)
+		res, ul := secretList()
+		w.exportLists(t.Context(), res, ul)
+		if res.Error != "" {
+			t.Fatal(res.Error)
+		}
+
+		files := checkDir(t, 1, tmpDir, "namespace-1")
+		if files[0].Name() != "bitnami.com.SealedSecret.yaml" {
+			t.Errorf("expected bitnami.com.SealedSecret.yaml, but got %s", files[0].Name())
+		}
+		l := unstructuredListFrom(t, tmpDir, "namespace-1", files[0].Name())
+		if len(l.Items) != 2 || l.Items[0].GetKind() != "SealedSecret" {
+			t.Errorf("expected 2 SealedSecrets, but got %v", l.Items)
+		}
+	})
+}
+
 func TestWorker_namespacesForResource(t *testing.T) {
 	w, _ := setupWorker(t)
 	namespaced, _ := getTestData()
@@ -320,3 +399,18 @@ func unstructuredFrom(t *testing.T, path ...string) *unstructured.Unstructured {
 	}
 	return u
 }
+
+// sealingCert returns a PEM encoded self-signed certificate of a new rsa key.
+func sealingCert(t *testing.T) string {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
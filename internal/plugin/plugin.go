@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+const (
+	// ResourceListAPIVersion api version of the KRM function ResourceList.
+	ResourceListAPIVersion = "config.kubernetes.io/v1"
+	// ResourceListKind kind of the KRM function ResourceList.
+	ResourceListKind = "ResourceList"
+
+	severityError = "error"
+	// waitDelay time to wait for the output pipes after the plugin was killed.
+	waitDelay = time.Second
+)
+
+// inheritedEnv the environment variables passed to the plugins, others like credentials are not leaked.
+var inheritedEnv = []string{"PATH", "HOME", "TMPDIR"}
+
+// ResourceList the KRM function input and output.
+type ResourceList struct {
+	APIVersion     string           `json:"apiVersion"`
+	Kind           string           `json:"kind"`
+	Items          []map[string]any `json:"items"`
+	FunctionConfig map[string]any   `json:"functionConfig,omitempty"`
+	Results        []Result         `json:"results,omitempty"`
+}
+
+// Result a KRM function result.
+type Result struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// Run executes the plugin. In batch mode all instances are passed at once, otherwise each instance is passed
+// on its own. On errors the instances are kept unchanged, or dropped if the plugin is configured to.
+func Run(ctx context.Context, p types.Plugin, items []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	if p.Batch {
+		return runOrKeep(ctx, p, items)
+	}
+	var result []unstructured.Unstructured
+	var errs []error
+	for _, item := range items {
+		out, err := runOrKeep(ctx, p, []unstructured.Unstructured{item})
+		if err != nil {
+			errs = append(errs, err)
+		}
+		result = append(result, out...)
+	}
+	return result, errors.Join(errs...)
+}
+
+func runOrKeep(ctx context.Context, p types.Plugin, items []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	out, err := run(ctx, p, items)
+	if err == nil {
+		return out, nil
+	}
+	err = fmt.Errorf("plugin %s: %w", p, err)
+	if p.DropOnError {
+		return nil, err
+	}
+	return items, err
+}
+
+func run(ctx context.Context, p types.Plugin, items []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	in := ResourceList{
+		APIVersion:     ResourceListAPIVersion,
+		Kind:           ResourceListKind,
+		FunctionConfig: p.FunctionConfig,
+	}
+	for _, item := range items {
+		in.Items = append(in.Items, item.Object)
+	}
+	stdin, err := yaml.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, p.GetTimeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	//nolint:gosec // the executable is configured by the user
+	cmd := exec.CommandContext(runCtx, p.Exec, p.Args...)
+	cmd.Env = environ(p.Env)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = waitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out after %s", p.GetTimeout())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return parse(stdout.Bytes())
+}
+
+// environ returns the inherited environment variables and the configured ones of the plugin.
+func environ(env []string) []string {
+	var result []string
+	for _, key := range inheritedEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			result = append(result, key+"="+value)
+		}
+	}
+	return append(result, env...)
+}
+
+// parse the plugin output, results with severity error are returned as error.
+func parse(out []byte) ([]unstructured.Unstructured, error) {
+	js, err := yaml.YAMLToJSON(out)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	rl := &ResourceList{}
+	// util json keeps integers as int64 as expected by unstructured objects
+	if err := utiljson.Unmarshal(js, rl); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	if rl.Kind != ResourceListKind {
+		return nil, fmt.Errorf("invalid output: expected kind %s but got %q", ResourceListKind, rl.Kind)
+	}
+
+	var msgs []string
+	for _, r := range rl.Results {
+		if r.Severity == severityError {
+			msgs = append(msgs, r.Message)
+		}
+	}
+	if len(msgs) > 0 {
+		return nil, errors.New(strings.Join(msgs, "; "))
+	}
+
+	items := make([]unstructured.Unstructured, 0, len(rl.Items))
+	for _, item := range rl.Items {
+		items = append(items, unstructured.Unstructured{Object: item})
+	}
+	return items, nil
+}
@@ -0,0 +1,166 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func items(names ...string) []unstructured.Unstructured {
+	var list []unstructured.Unstructured
+	for _, name := range names {
+		us := unstructured.Unstructured{}
+		us.SetAPIVersion("v1")
+		us.SetKind("ConfigMap")
+		us.SetName(name)
+		list = append(list, us)
+	}
+	return list
+}
+
+func shell(script string) types.Plugin {
+	return types.Plugin{Name: "test", Exec: "sh", Args: []string{"-c", script}}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   types.Plugin
+		expected []string
+		err      string
+	}{
+		{
+			name:     "should pass the instances through",
+			plugin:   shell("cat"),
+			expected: []string{"a", "b"},
+		},
+		{
+			name: "should replace the instances",
+			plugin: shell(`cat <<EOF
+apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: replaced
+    data:
+      replicas: 3
+EOF`),
+			expected: []string{"replaced", "replaced"},
+		},
+		{
+			name: "should drop the instances",
+			plugin: func() types.Plugin {
+				p := shell(`printf 'apiVersion: config.kubernetes.io/v1\nkind: ResourceList\nitems: []\n'`)
+				p.Batch = true
+				return p
+			}(),
+			expected: nil,
+		},
+		{
+			name:     "should keep the instances on error",
+			plugin:   shell("echo boom >&2; exit 1"),
+			expected: []string{"a", "b"},
+			err:      "plugin test: exit status 1: boom",
+		},
+		{
+			name: "should drop the instances on error",
+			plugin: func() types.Plugin {
+				p := shell("exit 1")
+				p.DropOnError = true
+				return p
+			}(),
+			expected: nil,
+			err:      "plugin test: exit status 1",
+		},
+		{
+			name: "should report error results",
+			plugin: func() types.Plugin {
+				p := shell(`printf 'apiVersion: config.kubernetes.io/v1\nkind: ResourceList\nitems: []\nresults:\n  - message: invalid\n    severity: error\n'`)
+				p.Batch = true
+				return p
+			}(),
+			expected: []string{"a", "b"},
+			err:      "plugin test: invalid",
+		},
+		{
+			name:     "should fail on invalid output",
+			plugin:   shell("echo foo"),
+			expected: []string{"a", "b"},
+			err:      "invalid output",
+		},
+		{
+			name: "should enforce the timeout",
+			plugin: func() types.Plugin {
+				p := shell("sleep 5")
+				p.Batch = true
+				p.Timeout = &metav1.Duration{Duration: 100 * time.Millisecond}
+				return p
+			}(),
+			expected: []string{"a", "b"},
+			err:      "plugin test: timed out after 100ms",
+		},
+		{
+			name: "should pass only the minimal and the configured environment",
+			plugin: func() types.Plugin {
+				p := shell(`test -z "$KUBEXPORTER_TEST_SECRET" && test "$PLUGIN_VALUE" = foo && test -n "$PATH" && cat`)
+				p.Env = []string{"PLUGIN_VALUE=foo"}
+				return p
+			}(),
+			expected: []string{"a", "b"},
+		},
+	}
+
+	t.Setenv("KUBEXPORTER_TEST_SECRET", "secret")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(t.Context(), tt.plugin, items("a", "b"))
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+			var names []string
+			for _, us := range got {
+				names = append(names, us.GetName())
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestRun_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	p := shell("sleep 5")
+	p.Batch = true
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	got, err := Run(ctx, p, items("a"))
+	if !errors.Is(err, context.Canceled) || strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected a cancel error, got %v", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("expected the instance to be kept, got %v", got)
+	}
+}
+
+func TestRun_int64(t *testing.T) {
+	got, err := Run(t.Context(), shell(`printf 'apiVersion: config.kubernetes.io/v1\nkind: ResourceList\nitems:\n  - spec:\n      replicas: 3\n'`), items("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, _ := unstructured.NestedInt64(got[0].Object, "spec", "replicas"); !ok || v != 3 {
+		t.Errorf("expected replicas to be int64 3, got %v", got[0].Object)
+	}
+}
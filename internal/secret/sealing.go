@@ -0,0 +1,31 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/bakito/kubexporter/internal/client"
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+// FetchSealingCert fetches the public certificate of the sealed-secrets controller via the service proxy.
+func FetchSealingCert(ctx context.Context, config *types.Config, namespace, name string) (string, error) {
+	apiClient, err := client.NewAPIClient(config)
+	if err != nil {
+		return "", err
+	}
+
+	return fetchSealingCertInternal(ctx, apiClient.DiscoveryClient.RESTClient(), namespace, name)
+}
+
+func fetchSealingCertInternal(ctx context.Context, rc rest.Interface, namespace, name string) (string, error) {
+	b, err := rc.Get().
+		AbsPath("/api/v1/namespaces", namespace, "services", "http:"+name+":", "proxy", "v1", "cert.pem").
+		DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("fetch the certificate of the sealed-secrets controller %s/%s: %w", namespace, name, err)
+	}
+	return string(b), nil
+}
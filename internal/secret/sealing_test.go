@@ -0,0 +1,34 @@
+package secret
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest/fake"
+)
+
+func Test_fetchSealingCertInternal(t *testing.T) {
+	rc := &fake.RESTClient{
+		Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			want := "/api/v1/namespaces/kube-system/services/http:sealed-secrets-controller:/proxy/v1/cert.pem"
+			if req.URL.Path != want {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("cert"))}, nil
+		}),
+	}
+
+	cert, err := fetchSealingCertInternal(t.Context(), rc, "kube-system", "sealed-secrets-controller")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cert != "cert" {
+		t.Errorf("expected cert, got %q", cert)
+	}
+
+	if _, err := fetchSealingCertInternal(t.Context(), rc, "other", "sealed-secrets-controller"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -44,6 +45,9 @@ const (
 	// ProgressNone no progress.
 	ProgressNone = Progress("none")
 
+	// DefaultOptOutKey default annotation or label key to exclude single instances.
+	DefaultOptOutKey = "kubexporter.io/exclude"
+
 	// DefaultMaskReplacement Default Mask Replacement.
 	DefaultMaskReplacement = "*****"
 )
@@ -131,7 +135,13 @@ func NewConfig(configFlags *genericclioptions.ConfigFlags, printFlags *genericcl
 		Encrypted: &Encrypted{
 			KindFields: KindFields{},
 		},
+		SealedSecrets: &SealedSecrets{
+			ControllerNamespace: DefaultSealedSecretsControllerNamespace,
+			ControllerName:      DefaultSealedSecretsControllerName,
+			Scope:               SealingScopeStrict,
+		},
 		Excluded: Excluded{
+			OptOutKey:       DefaultOptOutKey,
 			Fields:          DefaultExcludedFields,
 			KindFields:      KindFields{},
 			KindsByField:    make(map[string][]FieldValue),
@@ -145,40 +155,53 @@ func NewConfig(configFlags *genericclioptions.ConfigFlags, printFlags *genericcl
 
 // Config export config.
 type Config struct {
-	Excluded                Excluded      `docs:"Excluded resources"                                                     json:"excluded"                      yaml:"excluded"`
-	Included                Included      `docs:"Included resources"                                                     json:"included"                      yaml:"included"`
-	CreatedWithin           time.Duration `docs:"The max allowed age duration for the resources"                         docs-cli:"created-within"            json:"createdWithin"           yaml:"createdWithin"`
-	ConsiderOwnerReferences bool          `docs:"Consider owner references for not excluded resources"                   json:"considerOwnerReferences"       yaml:"considerOwnerReferences"`
-	Masked                  *Masked       `docs:"Field masking config"                                                   json:"masked"                        yaml:"masked"`
-	Encrypted               *Encrypted    `docs:"Field encryption config"                                                json:"encrypted"                     yaml:"encrypted"`
-	SortSlices              KindFields    `docs:"sort the slice field value before exporting"                            json:"sortSlices"                    yaml:"sortSlices"`
-	FileNameTemplate        string        `docs:"Custom resource file name template"                                     json:"fileNameTemplate"              yaml:"fileNameTemplate"`
-	ListFileNameTemplate    string        `docs:"Custom resource list file name template"                                json:"listFileNameTemplate"          yaml:"listFileNameTemplate"`
-	AsLists                 bool          `docs:"Export as lists instead of individual files"                            docs-cli:"lists"                     json:"asLists"                 yaml:"asLists"`
-	QueryPageSize           int           `docs:"Kubernetes query page size (0 use default)"                             json:"queryPageSize"                 yaml:"queryPageSize"`
-	Target                  string        `docs:"The target directory"                                                   docs-cli:"target"                    json:"target"                  yaml:"target"`
-	ClearTarget             bool          `docs:"Clear the target directory before exporting"                            docs-cli:"clear-target"              json:"clearTarget"             yaml:"clearTarget"`
-	Summary                 bool          `docs:"If enabled, a summary is printed"                                       docs-cli:"summary"                   json:"summary"                 yaml:"summary"`
-	Progress                Progress      `docs:"Progress mode bar|bubbles|simple|none"                                  docs-cli:"progress"                  json:"progress"                yaml:"progress"`
-	Namespace               *string       `docs:"A single namespace (default all)"                                       docs-cli:"namespace"                 json:"namespace,omitempty"     yaml:"namespace,omitempty"`
-	Namespaces              []string      `docs:"Multiple namespaces (joined with namespace, if both are set)"           json:"namespaces,omitempty"          yaml:"namespaces,omitempty"`
-	IncludeClusterResources bool          `docs:"Export cluster-scoped resources too, when a namespace filter is active" docs-cli:"include-cluster-resources" json:"includeClusterResources" yaml:"includeClusterResources"`
-	Worker                  int           `docs:"The number of parallel worker"                                          docs-cli:"worker"                    json:"worker"                  yaml:"worker"`
-	Archive                 bool          `docs:"Create a tar.gz archive"                                                docs-cli:"archive"                   json:"archive"                 yaml:"archive"`
-	ArchiveRetentionDays    int           `docs:"Number of days to keep old archives"                                    json:"archiveRetentionDays"          yaml:"archiveRetentionDays"`
-	ArchiveTarget           string        `docs:"The target directory for the archive(default \"exports\")"              json:"archiveTarget"                 yaml:"archiveTarget"`
-	S3Config                *S3Config     `docs:"S3 Configuration to upload the archive to an S3 compatible storage"     json:"s3"                            yaml:"s3"`
-	GCSConfig               *GCSConfig    `docs:"Google storage bucket configuration"                                    json:"gcs"                           yaml:"gcs"`
-	Metrics                 *Metrics      `docs:"Metrics configuration"                                                  json:"metrics"                       yaml:"metrics"`
-	Quiet                   bool          `docs:"Output is prevented"                                                    docs-cli:"quiet"                     json:"quiet"                   yaml:"quiet"`
-	Verbose                 bool          `docs:"Errors during export are listed in summary"                             docs-cli:"verbose"                   json:"verbose"                 yaml:"verbose"`
-	PrintSize               bool          `docs:"Print the size of the exported files"                                   docs-cli:"size"                      json:"printSize"               yaml:"printSize"`
-
-	excludedSet set
-	includedSet set
-	log         log.YALI
-	configFlags *genericclioptions.ConfigFlags
-	PrintFlags  *genericclioptions.PrintFlags `json:"-" yaml:"-"`
+	Excluded                Excluded            `docs:"Excluded resources"                                                     json:"excluded"                      yaml:"excluded"`
+	Included                Included            `docs:"Included resources"                                                     json:"included"                      yaml:"included"`
+	CreatedWithin           time.Duration       `docs:"The max allowed age duration for the resources"                         docs-cli:"created-within"            json:"createdWithin"               yaml:"createdWithin"`
+	ConsiderOwnerReferences bool                `docs:"Consider owner references for not excluded resources"                   json:"considerOwnerReferences"       yaml:"considerOwnerReferences"`
+	OwnerReferences         OwnerReferences     `docs:"Owner reference rules and owner graph output"                           json:"ownerReferences"               yaml:"ownerReferences"`
+	StripDefaults           []string            `docs:"Kind rules to strip fields equal to the OpenAPI schema default"         docs-cli:"strip-defaults"            json:"stripDefaults,omitempty"     yaml:"stripDefaults,omitempty"`
+	Neat                    bool                `docs:"Strip server-populated fields to get re-applicable manifests"           docs-cli:"neat"                      json:"neat"                        yaml:"neat"`
+	Transform               Transforms          `docs:"Kind specific transformations (set, delete, rename, replace, patch)"    json:"transform,omitempty"           yaml:"transform,omitempty"`
+	Plugins                 []Plugin            `docs:"Exec transformer plugins receiving the instances as KRM ResourceList"   json:"plugins,omitempty"             yaml:"plugins,omitempty"`
+	Masked                  *Masked             `docs:"Field masking config"                                                   json:"masked"                        yaml:"masked"`
+	Encrypted               *Encrypted          `docs:"Field encryption config"                                                json:"encrypted"                     yaml:"encrypted"`
+	SealedSecrets           *SealedSecrets      `docs:"Export Secrets as SealedSecrets of the sealed-secrets controller"       json:"sealedSecrets"                 yaml:"sealedSecrets"`
+	SortSlices              KindFields          `docs:"sort the slice field value before exporting"                            json:"sortSlices"                    yaml:"sortSlices"`
+	FileNameTemplate        string              `docs:"Custom resource file name template"                                     json:"fileNameTemplate"              yaml:"fileNameTemplate"`
+	ListFileNameTemplate    string              `docs:"Custom resource list file name template"                                json:"listFileNameTemplate"          yaml:"listFileNameTemplate"`
+	AsLists                 bool                `docs:"Export as lists instead of individual files"                            docs-cli:"lists"                     json:"asLists"                     yaml:"asLists"`
+	QueryPageSize           int                 `docs:"Kubernetes query page size (0 use default)"                             json:"queryPageSize"                 yaml:"queryPageSize"`
+	Target                  string              `docs:"The target directory"                                                   docs-cli:"target"                    json:"target"                      yaml:"target"`
+	ClearTarget             bool                `docs:"Clear the target directory before exporting"                            docs-cli:"clear-target"              json:"clearTarget"                 yaml:"clearTarget"`
+	Summary                 bool                `docs:"If enabled, a summary is printed"                                       docs-cli:"summary"                   json:"summary"                     yaml:"summary"`
+	Progress                Progress            `docs:"Progress mode bar|bubbles|simple|none"                                  docs-cli:"progress"                  json:"progress"                    yaml:"progress"`
+	Namespace               *string             `docs:"A single namespace (default all)"                                       docs-cli:"namespace"                 json:"namespace,omitempty"         yaml:"namespace,omitempty"`
+	Namespaces              []string            `docs:"Multiple namespaces, globs or /regexes/ (joined with namespace)"        json:"namespaces,omitempty"          yaml:"namespaces,omitempty"`
+	NamespaceSelector       string              `docs:"Label selector to select namespaces e.g. 'tenant=foo'"                  docs-cli:"namespace-selector"        json:"namespaceSelector,omitempty" yaml:"namespaceSelector,omitempty"`
+	IncludeClusterResources bool                `docs:"Export cluster-scoped resources too, when namespaces are selected"      docs-cli:"include-cluster-resources" json:"includeClusterResources"     yaml:"includeClusterResources"`
+	Selector                Selector            `docs:"Server-side label and field selector for all kinds"                     json:"selector"                      yaml:"selector"`
+	KindSelectors           map[string]Selector `docs:"Kind specific server-side selectors, combined with the global selector" json:"kindSelectors"                 yaml:"kindSelectors"`
+	NamespaceMapping        NamespaceMapping    `docs:"Map exported namespaces to other namespaces (source: target)"           docs-cli:"namespace-mapping"         json:"namespaceMapping,omitempty"  yaml:"namespaceMapping,omitempty"`
+	Worker                  int                 `docs:"The number of parallel worker"                                          docs-cli:"worker"                    json:"worker"                      yaml:"worker"`
+	Archive                 bool                `docs:"Create a tar.gz archive"                                                docs-cli:"archive"                   json:"archive"                     yaml:"archive"`
+	ArchiveRetentionDays    int                 `docs:"Number of days to keep old archives"                                    json:"archiveRetentionDays"          yaml:"archiveRetentionDays"`
+	ArchiveTarget           string              `docs:"The target directory for the archive(default \"exports\")"              json:"archiveTarget"                 yaml:"archiveTarget"`
+	S3Config                *S3Config           `docs:"S3 Configuration to upload the archive to an S3 compatible storage"     json:"s3"                            yaml:"s3"`
+	GCSConfig               *GCSConfig          `docs:"Google storage bucket configuration"                                    json:"gcs"                           yaml:"gcs"`
+	Metrics                 *Metrics            `docs:"Metrics configuration"                                                  json:"metrics"                       yaml:"metrics"`
+	Quiet                   bool                `docs:"Output is prevented"                                                    docs-cli:"quiet"                     json:"quiet"                       yaml:"quiet"`
+	Verbose                 bool                `docs:"Errors during export are listed in summary"                             docs-cli:"verbose"                   json:"verbose"                     yaml:"verbose"`
+	PrintSize               bool                `docs:"Print the size of the exported files"                                   docs-cli:"size"                      json:"printSize"                   yaml:"printSize"`
+
+	ownerGraph         *OwnerGraph
+	ownerGraphOnce     sync.Once
+	schemaDefaults     *SchemaDefaults
+	schemaDefaultsOnce sync.Once
+	log                log.YALI
+	configFlags        *genericclioptions.ConfigFlags
+	namespacesSelected bool
+	PrintFlags         *genericclioptions.PrintFlags `json:"-" yaml:"-"`
 }
 
 func (c *Config) MaxArchiveAge() time.Time {
@@ -187,7 +210,13 @@ func (c *Config) MaxArchiveAge() time.Time {
 
 // HasNamespaces returns true if export is limited to one or more namespaces.
 func (c *Config) HasNamespaces() bool {
-	return len(c.Namespaces) > 0
+	return len(c.selectedNamespaces()) > 0
+}
+
+// SelectsNamespaces returns true if namespaces are selected by name or pattern, excluded namespaces
+// or a namespace selector alone do not restrict the export to namespaced resources.
+func (c *Config) SelectsNamespaces() bool {
+	return c.namespacesSelected
 }
 
 func (c *Config) normalizeNamespaces() {
@@ -206,6 +235,7 @@ func (c *Config) normalizeNamespaces() {
 		ns = slices.Compact(ns)
 	}
 	c.Namespaces = ns
+	c.namespacesSelected = c.HasNamespaces()
 }
 
 func EmptyNamespaces() []string {
@@ -240,16 +270,26 @@ type Progress string
 
 // Excluded exclusion params.
 type Excluded struct {
-	Kinds           []string                `docs:"List all kinds to be excluded"                                                                   docs-cli:"exclude-kinds" json:"kinds"           yaml:"kinds"`
-	Fields          [][]string              `docs:"List fields that should be removed for all resources before exported; slices are also traversed" json:"fields"            yaml:"fields"`
-	KindFields      KindFields              `docs:"Kind specific excluded fields"                                                                   json:"kindFields"        yaml:"kindFields"`
-	KindsByField    map[string][]FieldValue `docs:"Allows to exclude single instances with certain field values"                                    json:"kindByField"       yaml:"kindByField"`
-	PreservedFields PreservedFields         `docs:"List of fields to be preserved"                                                                  json:"preservedFields"   yaml:"preservedFields"`
+	Namespaces      []string                `docs:"Namespaces to be excluded; globs and /regexes/ are supported"                                    docs-cli:"exclude-namespaces" json:"namespaces,omitempty"  yaml:"namespaces,omitempty"`
+	Kinds           []string                `docs:"List all kinds to be excluded"                                                                   docs-cli:"exclude-kinds"      json:"kinds"                 yaml:"kinds"`
+	Fields          [][]string              `docs:"List fields that should be removed for all resources before exported; slices are also traversed" json:"fields"                 yaml:"fields"`
+	KindFields      KindFields              `docs:"Kind specific excluded fields"                                                                   json:"kindFields"             yaml:"kindFields"`
+	KindsByField    map[string][]FieldValue `docs:"Allows to exclude single instances with certain field values"                                    json:"kindByField"            yaml:"kindByField"`
+	Expressions     map[string][]string     `docs:"Kind specific CEL expressions, instances matching one of the expressions are excluded"           json:"expressions,omitempty"  yaml:"expressions,omitempty"`
+	Names           map[string][]string     `docs:"Kind specific name globs or /regexes/ of instances to be excluded"                               json:"names,omitempty"        yaml:"names,omitempty"`
+	OptOutKey       string                  `docs:"Annotation or label key to exclude single instances with value 'true'"                           json:"optOutKey"              yaml:"optOutKey"`
+	PreservedFields PreservedFields         `docs:"List of fields to be preserved"                                                                  json:"preservedFields"        yaml:"preservedFields"`
 }
 
 // PreservedFields defines fields that should be preserved when their parent field is excluded.
 type PreservedFields struct {
-	Fields [][]string `docs:"Fields to be preserved" json:"fields" yaml:"fields"` // Global preserved fields
+	Fields     [][]string `docs:"Fields to be preserved"               json:"fields"     yaml:"fields"` // Global preserved fields
+	KindFields KindFields `docs:"Kind specific fields to be preserved" json:"kindFields" yaml:"kindFields"`
+}
+
+// ForResource returns the global and the kind specific preserved fields of the group resource.
+func (p PreservedFields) ForResource(gr *GroupResource) [][]string {
+	return append(slices.Clone(p.Fields), p.KindFields.ForResource(gr)...)
 }
 
 // KindFields map kinds to fields.
@@ -277,6 +317,18 @@ func (f KindFields) Diff(other KindFields) KindFields {
 	return diff
 }
 
+// categoryRules returns the sorted 'category:' rules of the kind fields.
+func (f KindFields) categoryRules() []string {
+	var rules []string
+	for k := range f {
+		if strings.HasPrefix(k, kindRuleCategoryPrefix) {
+			rules = append(rules, k)
+		}
+	}
+	slices.Sort(rules)
+	return rules
+}
+
 func (f KindFields) String() string {
 	var kinds []string
 	for k, v := range f {
@@ -320,7 +372,10 @@ func diffFields(this, other [][]string) [][]string {
 
 // Included inclusion params.
 type Included struct {
-	Kinds []string `docs:"List all kinds to be included" docs-cli:"include-kinds" json:"kinds" yaml:"kinds"`
+	Kinds       []string            `docs:"List all kinds to be included"                                                              docs-cli:"include-kinds"     json:"kinds"                 yaml:"kinds"`
+	Expressions map[string][]string `docs:"Kind specific CEL expressions, only instances matching one of the expressions are included" json:"expressions,omitempty" yaml:"expressions,omitempty"`
+	Names       map[string][]string `docs:"Kind specific name globs or /regexes/, only matching instances are included"                json:"names,omitempty"       yaml:"names,omitempty"`
+	OptInKey    string              `docs:"Label key to export single instances of excluded kinds with value 'true'"                   json:"optInKey"              yaml:"optInKey"`
 }
 
 // FieldValue field with value.
@@ -330,266 +385,274 @@ type FieldValue struct {
 }
 
 // FilterFields filter fields for a given resource.
+// Preserved fields are restored, if they were removed together with an excluded parent field.
 func (c *Config) FilterFields(res *GroupResource, us unstructured.Unstructured) {
-	// First, collect all preserved values before removing fields
-	preservedValues := make(map[string]any)
-
-	// Collect global preserved values
-	for _, excludeField := range c.Excluded.Fields {
-		for _, preservedField := range c.Excluded.PreservedFields.Fields {
-			if len(preservedField) > len(excludeField) {
-				// Check if this preserved field starts with our exclude path
-				isSubField := true
-				for i, excludeFieldPart := range excludeField {
-					if i >= len(preservedField) || preservedField[i] != excludeFieldPart {
-						isSubField = false
-						break
-					}
-				}
-
-				if isSubField {
-					// This is a sub-field we want to preserve
-					// Navigate to the parent field first
-					m := us.Object
-					for _, field := range excludeField {
-						x, ok := m[field].(map[string]any)
-						if !ok {
-							break
-						}
-						m = x
-					}
-
-					// Now navigate to the sub-field
-					subPath := preservedField[len(excludeField):]
-					subM := m
-					for _, pathPart := range subPath[:len(subPath)-1] {
-						x, ok := subM[pathPart].(map[string]any)
-						if !ok {
-							break
-						}
-						subM = x
-					}
-
-					// Get the final value
-					finalField := subPath[len(subPath)-1]
-					if value, exists := subM[finalField]; exists {
-						preservedKey := strings.Join(subPath, ".")
-						preservedValues[preservedKey] = value
-					}
-				}
-			}
+	type preservedValue struct {
+		field []string
+		value any
+	}
+	var preserved []preservedValue
+	for _, f := range c.Excluded.PreservedFields.ForResource(res) {
+		// patterns and list selectors are rejected by Validate, as the value could not be restored
+		fields, ok := fieldPathFor(f...).plain()
+		if !ok {
+			continue
+		}
+		if value, ok, err := unstructured.NestedFieldCopy(us.Object, fields...); ok && err == nil {
+			preserved = append(preserved, preservedValue{field: fields, value: value})
 		}
 	}
 
-	// Now remove the excluded fields
 	for _, f := range c.Excluded.Fields {
 		removeNestedField(us.Object, f...)
 	}
-
-	// Remove kind-specific excluded fields
-	gk := res.GroupKind()
-	if c.Excluded.KindFields != nil && c.Excluded.KindFields[gk] != nil {
-		for _, f := range c.Excluded.KindFields[gk] {
-			removeNestedField(us.Object, f...)
-		}
+	for _, f := range c.Excluded.KindFields.ForResource(res) {
+		removeNestedField(us.Object, f...)
 	}
 
-	// Finally, restore preserved values
-	for key, value := range preservedValues {
-		// Find the corresponding exclude field for this preserved value
-		for _, excludeField := range c.Excluded.Fields {
-			for _, preservedField := range c.Excluded.PreservedFields.Fields {
-				if len(preservedField) > len(excludeField) {
-					// Check if this preserved field starts with our exclude path
-					isSubField := true
-					for i, excludeFieldPart := range excludeField {
-						if i >= len(preservedField) || preservedField[i] != excludeFieldPart {
-							isSubField = false
-							break
-						}
-					}
-
-					if isSubField {
-						subPathCheck := preservedField[len(excludeField):]
-						subPathCheckStr := strings.Join(subPathCheck, ".")
-						if subPathCheckStr == key {
-							// This is the matching preserved field, restore it under the exclude field
-							restorePath := make([]string, len(excludeField)+len(subPathCheck))
-							copy(restorePath, excludeField)
-							copy(restorePath[len(excludeField):], subPathCheck)
-							_ = unstructured.SetNestedField(us.Object, value, restorePath...)
-							break
-						}
-					}
-				}
-			}
+	for _, p := range preserved {
+		if _, ok, _ := unstructured.NestedFieldNoCopy(us.Object, p.field...); !ok {
+			_ = unstructured.SetNestedField(us.Object, p.value, p.field...)
 		}
 	}
 }
 
-// removeNestedField removes the nested field from the obj.
-func removeNestedField(obj map[string]any, fields ...string) {
-	m := obj
-	for i, field := range fields[:len(fields)-1] {
-		var x map[string]any
-		var ok bool
-		if x, ok = m[field].(map[string]any); !ok {
-			if x, ok := m[field].([]any); ok {
-				for _, y := range x {
-					if yy, ok := y.(map[string]any); ok {
-						removeNestedField(yy, fields[i+1:]...)
-					}
-				}
-			}
+// removeNestedField removes the nested field from the obj and returns the number of removed fields.
+func removeNestedField(obj map[string]any, fields ...string) int {
+	removed := 0
+	fieldPathFor(fields...).walk(obj, func(m map[string]any, key string, items []int) {
+		if items == nil {
+			delete(m, key)
+			removed++
 			return
 		}
-
-		m = x
-	}
-	delete(m, fields[len(fields)-1])
+		removed += len(items)
+		//nolint:forcetypeassert
+		list := m[key].([]any)
+		kept := make([]any, 0, len(list)-len(items))
+		for i, item := range list {
+			if !slices.Contains(items, i) {
+				kept = append(kept, item)
+			}
+		}
+		m[key] = kept
+	})
+	return removed
 }
 
-// removeNestedFieldWithPreservation removes the nested field from the obj but preserves specified sub-fields.
-
 func transformNestedFields(
 	kf KindFields,
-	transform func(val any) string,
-	gk string,
+	transform func(val any) any,
+	res *GroupResource,
 	us unstructured.Unstructured,
 ) {
-	if kf != nil && kf[gk] != nil {
-		for _, f := range kf[gk] {
-			transformNestedField(us.Object, transform, f...)
-		}
+	for _, f := range kf.ForResource(res) {
+		transformNestedField(us.Object, transform, f...)
 	}
 }
 
 // transformNestedField transforms the nested field from the obj.
-func transformNestedField(obj map[string]any, transform func(val any) string, fields ...string) {
-	m := obj
-	for i, field := range fields[:len(fields)-1] {
-		var x map[string]any
-		var ok bool
-		if x, ok = m[field].(map[string]any); !ok {
-			if x, ok := m[field].([]any); ok {
-				for _, y := range x {
-					if yy, ok := y.(map[string]any); ok {
-						transformNestedField(yy, transform, fields[i+1:]...)
-					}
-				}
+func transformNestedField(obj map[string]any, transform func(val any) any, fields ...string) {
+	fieldPathFor(fields...).walk(obj, func(m map[string]any, key string, items []int) {
+		if items == nil {
+			if v, ok := transformValue(m[key], transform); ok {
+				m[key] = v
 			}
 			return
 		}
+		//nolint:forcetypeassert
+		list := m[key].([]any)
+		for _, i := range items {
+			if v, ok := transformValue(list[i], transform); ok {
+				list[i] = v
+			}
+		}
+	})
+}
 
-		m = x
-	}
-	switch e := m[fields[len(fields)-1]].(type) {
+// transformValue transforms all leaf values of nested maps and lists, nil values are not transformed.
+func transformValue(val any, transform func(val any) any) (any, bool) {
+	switch e := val.(type) {
+	case nil:
+		return nil, false
 	case map[string]any:
-		for k := range e {
-			e[k] = transform(e[k])
+		for k, v := range e {
+			if t, ok := transformValue(v, transform); ok {
+				e[k] = t
+			}
+		}
+		return e, true
+	case []any:
+		for i, v := range e {
+			if t, ok := transformValue(v, transform); ok {
+				e[i] = t
+			}
 		}
-	case string:
-		m[fields[len(fields)-1]] = transform(m[fields[len(fields)-1]])
+		return e, true
 	}
+	return transform(val), true
 }
 
 // SortSliceFields sort fields for a given resource.
 func (c *Config) SortSliceFields(res *GroupResource, us unstructured.Unstructured) {
-	gk := res.GroupKind()
-	if c.SortSlices != nil && c.SortSlices[gk] != nil {
-		for _, f := range c.SortSlices[gk] {
-			if sl, ok, err := unstructured.NestedSlice(us.Object, f...); ok && err == nil {
-				if len(sl) > 0 {
-					switch sl[0].(type) {
-					case string:
-						slices.SortFunc(sl, func(a, b any) int {
-							//nolint:forcetypeassert
-							return strings.Compare(a.(string), b.(string))
-						})
-					case int64:
-						slices.SortFunc(sl, func(a, b any) int {
-							//nolint:forcetypeassert
-							return int(a.(int64)) - int(b.(int64))
-						})
-					case float64:
-						slices.SortFunc(sl, func(a, b any) int {
-							//nolint:forcetypeassert
-							fa, fb := a.(float64), b.(float64)
-							if fa < fb {
-								return -1
-							}
-							if fa > fb {
-								return 1
-							}
-							return 0
-						})
-					default:
-						slices.SortFunc(sl, func(a, b any) int {
-							aa, _ := json.Marshal(a)
-							bb, _ := json.Marshal(b)
-							return bytes.Compare(aa, bb)
-						})
-					}
-					_ = unstructured.SetNestedSlice(us.Object, sl, f...)
+	for _, f := range c.SortSlices.ForResource(res) {
+		if sl, ok, err := unstructured.NestedSlice(us.Object, f...); ok && err == nil {
+			if len(sl) > 0 {
+				switch sl[0].(type) {
+				case string:
+					slices.SortFunc(sl, func(a, b any) int {
+						//nolint:forcetypeassert
+						return strings.Compare(a.(string), b.(string))
+					})
+				case int64:
+					slices.SortFunc(sl, func(a, b any) int {
+						//nolint:forcetypeassert
+						return int(a.(int64)) - int(b.(int64))
+					})
+				case float64:
+					slices.SortFunc(sl, func(a, b any) int {
+						//nolint:forcetypeassert
+						fa, fb := a.(float64), b.(float64)
+						if fa < fb {
+							return -1
+						}
+						if fa > fb {
+							return 1
+						}
+						return 0
+					})
+				default:
+					slices.SortFunc(sl, func(a, b any) int {
+						aa, _ := json.Marshal(a)
+						bb, _ := json.Marshal(b)
+						return bytes.Compare(aa, bb)
+					})
 				}
+				_ = unstructured.SetNestedSlice(us.Object, sl, f...)
 			}
 		}
 	}
 }
 
 // IsExcluded check if the group resource is excluded.
+// If included kinds are defined, only matching kinds are exported. If a kind matches included and excluded rules,
+// the more specific rule wins (exact kind > glob or regex > group > category > '*'), on equal specificity included wins.
 func (c *Config) IsExcluded(gr *GroupResource) bool {
-	if len(c.Included.Kinds) > 0 {
-		if c.includedSet == nil {
-			c.includedSet = newSet(c.Included.Kinds...)
-		}
-
-		return !c.includedSet.contains(gr.GroupKind())
-	}
-
-	if c.excludedSet == nil {
-		c.excludedSet = newSet(c.Excluded.Kinds...)
+	included := bestKindMatch(c.Included.Kinds, gr)
+	if len(c.Included.Kinds) > 0 && included == noMatch {
+		return true
 	}
-	return c.excludedSet.contains(gr.GroupKind())
+	return bestKindMatch(c.Excluded.Kinds, gr) > included
 }
 
 // IsInstanceExcluded check if the kind instance is excluded.
+// The rule that excluded the instance is recorded in the group resource.
 func (c *Config) IsInstanceExcluded(res *GroupResource, us unstructured.Unstructured) bool {
-	if c.isExcludedByOwnerReference(us) {
-		return true
+	rule := c.markedBy(res, us)
+	if rule != "" {
+		res.addExcludedBy(rule)
+	}
+	if c.recordsOwnerGraph() {
+		c.OwnerGraph().Add(&us, rule == "")
+	}
+	return rule != ""
+}
+
+// excludedBy returns the rule that excludes the instance or an empty string if the instance is not excluded.
+func (c *Config) excludedBy(res *GroupResource, us unstructured.Unstructured) string {
+	if rule := c.excludedByOwnerReference(us); rule != "" {
+		return rule
 	}
 	if c.CreatedWithin > 0 && us.GetCreationTimestamp().Time.Before(time.Now().Add(-c.CreatedWithin)) {
-		return true
+		return "created within " + c.CreatedWithin.String()
+	}
+	if rule := c.excludedByName(res, us); rule != "" {
+		return rule
 	}
-	if fvs, ok := c.Excluded.KindsByField[res.GroupKind()]; ok {
-		for _, fv := range fvs {
+	for _, key := range matchingKindKeys(c.Excluded.KindsByField, res) {
+		for _, fv := range c.Excluded.KindsByField[key] {
 			for _, v := range fv.Values {
 				if matches(us, fv.Field, v) {
-					return true
+					return fmt.Sprintf("field %s=%s", strings.Join(fv.Field, "."), v)
 				}
 			}
 		}
 	}
-	return false
+	return c.excludedByExpression(res, us)
 }
 
-func (c *Config) isExcludedByOwnerReference(us unstructured.Unstructured) bool {
-	if c.ConsiderOwnerReferences && len(us.GetOwnerReferences()) > 0 {
-		for _, or := range us.GetOwnerReferences() {
-			gv, err := schema.ParseGroupVersion(or.APIVersion)
-			r := &GroupResource{
-				APIGroup:        gv.Group,
-				APIVersion:      gv.Version,
-				APIGroupVersion: gv.String(),
-				APIResource:     metav1.APIResource{Kind: or.Kind},
-			}
+// OwnerGraph get the owner graph of the export.
+func (c *Config) OwnerGraph() *OwnerGraph {
+	c.ownerGraphOnce.Do(func() {
+		c.ownerGraph = NewOwnerGraph()
+	})
+	return c.ownerGraph
+}
+
+func (c *Config) recordsOwnerGraph() bool {
+	return c.OwnerReferences.Transitive || len(c.OwnerReferences.Graph) > 0
+}
+
+func (c *Config) excludedByOwnerReference(us unstructured.Unstructured) string {
+	if !c.ConsiderOwnerReferences && len(c.OwnerReferences.ExcludeOwnedBy) == 0 {
+		return ""
+	}
+	var rule string
+	c.visitOwners(us, func(ref metav1.OwnerReference) bool {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return false
+		}
+		r := &GroupResource{
+			APIGroup:        gv.Group,
+			APIVersion:      gv.Version,
+			APIGroupVersion: gv.String(),
+			APIResource:     metav1.APIResource{Kind: ref.Kind},
+		}
+		if (c.ConsiderOwnerReferences && c.IsExcluded(r)) || c.isExcludedOwnerKind(r) {
+			rule = "owned by " + r.GroupKind()
+		}
+		return rule != ""
+	})
+	return rule
+}
 
-			if err == nil && c.IsExcluded(r) {
-				return true
+func (c *Config) isExcludedOwnerKind(r *GroupResource) bool {
+	return bestKindMatch(c.OwnerReferences.ExcludeOwnedBy, r) != noMatch
+}
+
+// visitOwners calls visit for the owners of the resource until visit returns true.
+// If transitive, the owners of the owners are visited as well, resolved via the owner graph.
+// The graph is filled concurrently by the workers, owners not yet added are looked up,
+// so owners that can not be looked up resolve depending on the export order.
+func (c *Config) visitOwners(us unstructured.Unstructured, visit func(ref metav1.OwnerReference) bool) {
+	type pending struct {
+		ref       metav1.OwnerReference
+		namespace string
+	}
+	var queue []pending
+	for _, ref := range us.GetOwnerReferences() {
+		queue = append(queue, pending{ref: ref, namespace: us.GetNamespace()})
+	}
+
+	visited := newSet(string(us.GetUID()))
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if !c.OwnerReferences.follows(p.ref) || (p.ref.UID != "" && visited.contains(string(p.ref.UID))) {
+			continue
+		}
+		visited.add(string(p.ref.UID))
+		if visit(p.ref) {
+			return
+		}
+		if c.OwnerReferences.Transitive {
+			owner := c.OwnerGraph().Owner(p.ref, p.namespace)
+			for _, ref := range owner.Owners {
+				queue = append(queue, pending{ref: ref, namespace: owner.Namespace})
 			}
 		}
 	}
-	return false
 }
 
 func matches(us unstructured.Unstructured, field []string, filter string) bool {
@@ -602,6 +665,13 @@ func matches(us unstructured.Unstructured, field []string, filter string) bool {
 	return false
 }
 
+// MapNamespaces maps the namespace and the namespace references of the resource.
+// References that could not be mapped are recorded in the group resource.
+func (c *Config) MapNamespaces(res *GroupResource, us *unstructured.Unstructured) {
+	_, unmapped := c.NamespaceMapping.Apply(us)
+	res.UnmappedNamespaceReferences = append(res.UnmappedNamespaceReferences, unmapped...)
+}
+
 // FileName generate export file name.
 func (c *Config) FileName(res *GroupResource, us *unstructured.Unstructured, index int) (string, error) {
 	name := us.GetName()
@@ -658,6 +728,35 @@ func (c *Config) Validate() error {
 	if c.Worker <= 0 {
 		return errors.New("worker must be > 0")
 	}
+	if err := c.validateNamespaces(); err != nil {
+		return err
+	}
+	if err := c.validateSelectors(); err != nil {
+		return err
+	}
+	if err := c.validateKindRules(); err != nil {
+		return err
+	}
+	if err := c.validateExpressions(); err != nil {
+		return err
+	}
+	if err := c.validateNames(); err != nil {
+		return err
+	}
+	if err := c.validateFieldPaths(); err != nil {
+		return err
+	}
+	if err := c.validateTransform(); err != nil {
+		return err
+	}
+	if err := c.validatePlugins(); err != nil {
+		return err
+	}
+	for _, f := range c.OwnerReferences.Graph {
+		if f != OwnerGraphDOT && f != OwnerGraphJSON {
+			return fmt.Errorf("invalid owner graph format %q, must be one of %s|%s", f, OwnerGraphDOT, OwnerGraphJSON)
+		}
+	}
 
 	abs, err := filepath.Abs(c.Target)
 	if err != nil {
@@ -593,18 +593,92 @@ func TestConfig_MaskFields(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "should generate the hmac-sha256 checksum",
+			checksum: "hmac-sha256",
+			validate: func(t *testing.T, u *unstructured.Unstructured) {
+				t.Helper()
+				if u.Object["status"] != "9c196e32dc0175f86f4b1cb89289d6619de6bee699e4c378e68309ed97a1a6ab" {
+					t.Errorf("expected hmac-sha256 hash, but got %v", u.Object["status"])
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := us.DeepCopy()
 			config.Masked.Checksum = tt.checksum
-			_ = config.Masked.Setup()
+			config.Masked.HmacKey = "key"
+			if err := config.Masked.Setup(); err != nil {
+				t.Fatal(err)
+			}
 			config.MaskFields(res, *c)
 			tt.validate(t, c)
 		})
 	}
 
+	t.Run("should read the hmac key from env", func(t *testing.T) {
+		t.Setenv(types.EnvHmacKey, "other")
+		config.Masked.Checksum = "hmac-sha256"
+		config.Masked.HmacKey = "key"
+		if err := config.Masked.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		c := us.DeepCopy()
+		config.MaskFields(res, *c)
+		if c.Object["status"] == "9c196e32dc0175f86f4b1cb89289d6619de6bee699e4c378e68309ed97a1a6ab" {
+			t.Error("expected the env key to be used")
+		}
+	})
+
+	t.Run("should mask all string leaves of nested maps and lists", func(t *testing.T) {
+		config.Masked = &types.Masked{
+			Replacement: "***",
+			KindFields: map[string][][]string{
+				"group.kind": {{"spec", "replicas"}, {"spec", "enabled"}, {"spec", "args"}, {"spec", "env"}, {"spec", "config"}},
+			},
+		}
+		if err := config.Masked.Setup(); err != nil {
+			t.Fatal(err)
+		}
+		c := &unstructured.Unstructured{Object: map[string]any{
+			"kind": "kind",
+			"spec": map[string]any{
+				"replicas": int64(3),
+				"enabled":  true,
+				"args":     []any{"--debug"},
+				"env":      map[string]any{"USER": "user", "PORT": int64(80)},
+				"config": map[string]any{
+					"db":   map[string]any{"password": "hunter2"},
+					"list": []any{"secret1", map[string]any{"token": "secret2"}},
+				},
+			},
+		}}
+		config.MaskFields(res, *c)
+		expected := map[string]any{
+			"replicas": int64(3),
+			"enabled":  true,
+			"args":     []any{"***"},
+			"env":      map[string]any{"USER": "***", "PORT": int64(80)},
+			"config": map[string]any{
+				"db":   map[string]any{"password": "***"},
+				"list": []any{"***", map[string]any{"token": "***"}},
+			},
+		}
+		if !reflect.DeepEqual(c.Object["spec"], expected) {
+			t.Errorf("expected %v, but got %v", expected, c.Object["spec"])
+		}
+	})
+
+	t.Run("should fail with hmac-sha256 without key", func(t *testing.T) {
+		config.Masked.Checksum = "hmac-sha256"
+		config.Masked.HmacKey = ""
+		if err := config.Masked.Setup(); err == nil {
+			t.Error("expected error")
+		}
+	})
+
 	t.Run("should fail with an unknown checksum", func(t *testing.T) {
 		config.Masked.Checksum = "foo"
 		err := config.Masked.Setup()
@@ -800,4 +874,72 @@ func TestConfig_PreservedFields(t *testing.T) {
 			t.Error("expected phase to be removed")
 		}
 	})
+
+	t.Run("should preserve kind specific fields when excluding status by kind", func(t *testing.T) {
+		config.Excluded = types.Excluded{
+			KindFields: types.KindFields{
+				"*": {{"status"}},
+			},
+			PreservedFields: types.PreservedFields{
+				KindFields: types.KindFields{
+					"group.kind":  {{"status", "loadBalancer"}},
+					"group.other": {{"status", "conditions"}},
+				},
+			},
+		}
+
+		us := unstructured.Unstructured{
+			Object: map[string]any{
+				"kind": "kind",
+				"status": map[string]any{
+					"conditions":   []any{map[string]any{"type": "Ready"}},
+					"loadBalancer": map[string]any{"ingress": []any{map[string]any{"ip": "192.168.1.100"}}},
+				},
+			},
+		}
+
+		config.FilterFields(res, us)
+
+		if _, ok, _ := unstructured.NestedMap(us.Object, "status", "loadBalancer"); !ok {
+			t.Error("expected loadBalancer to be present")
+		}
+		if _, ok, _ := unstructured.NestedSlice(us.Object, "status", "conditions"); ok {
+			t.Error("expected conditions of another kind not to be preserved")
+		}
+	})
+
+	t.Run("should preserve a field addressed by a jsonpath", func(t *testing.T) {
+		config.Excluded = types.Excluded{
+			Fields: [][]string{{"metadata", "annotations"}},
+			PreservedFields: types.PreservedFields{
+				Fields: [][]string{{"$.metadata.annotations['example.com/keep']"}},
+			},
+		}
+
+		us := unstructured.Unstructured{Object: map[string]any{"kind": "kind"}}
+		us.SetAnnotations(map[string]string{"example.com/keep": "yes", "example.com/drop": "no"})
+
+		config.FilterFields(res, us)
+
+		if annotations := us.GetAnnotations(); !reflect.DeepEqual(annotations, map[string]string{"example.com/keep": "yes"}) {
+			t.Errorf("expected only the preserved annotation, got %v", annotations)
+		}
+	})
+}
+
+func TestConfig_Validate_preservedFields(t *testing.T) {
+	for name, preserved := range map[string]types.PreservedFields{
+		"regex":         {Fields: [][]string{{"status", "/^load/"}}},
+		"list selector": {KindFields: types.KindFields{"Service": {{"status", "conditions", "[0]"}}}},
+		"jsonpath glob": {Fields: [][]string{{"$.metadata.annotations['*.openshift.io/*']"}}},
+		"invalid path":  {Fields: [][]string{{"$."}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Excluded.PreservedFields = preserved
+			if err := config.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
 }
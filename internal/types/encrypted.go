@@ -3,211 +3,723 @@ package types
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
+	"filippo.io/age"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/bakito/kubexporter/internal/render"
 	"github.com/bakito/kubexporter/internal/utils"
+	"github.com/bakito/kubexporter/internal/vfs"
 )
 
 const (
 	prefix    = "KUBEXPORTER_AES@"
 	EnvAesKey = "KUBEXPORTER_AES_KEY"
+
+	// formatV3 marks values encrypted with a random nonce per value and the id of the key,
+	// the plaintext is the JSON encoding of the value to preserve its type:
+	// KUBEXPORTER_AES@v3:<key id>:<base64 nonce, ciphertext and tag>.
+	formatV3 = "v3:"
+	// values encrypted with a key derived from a passphrase contain the kdf, its parameters and salt as header:
+	// KUBEXPORTER_AES@v3:<key id>:<kdf>$<parameters>$<base64 salt>:<base64 nonce, ciphertext and tag>.
+	// keyIDLength number of bytes of the key hash used as key id.
+	keyIDLength = 4
 )
 
+// DefaultEncryptedKindFields the fields encrypted by the encrypt command if the config defines no fields to encrypt.
+var DefaultEncryptedKindFields = KindFields{
+	"Secret": {{"data"}, {"stringData"}},
+}
+
 type Encrypted struct {
-	AesKey     string     `docs:"The AES key to use for field encryption" json:"aesKey"     yaml:"aesKey"`
-	KindFields KindFields `docs:"The fields to encrypt for each kind"     json:"kindFields" yaml:"kindFields"`
-	gcm        cipher.AEAD
-	nonce      []byte
+	AesKey              string     `docs:"The AES key to use for field encryption"                      json:"aesKey"                        yaml:"aesKey"`
+	AesKeyFile          string     `docs:"A file containing the AES key"                                json:"aesKeyFile,omitempty"          yaml:"aesKeyFile,omitempty"`
+	KindFields          KindFields `docs:"The fields to encrypt for each kind"                          json:"kindFields"                    yaml:"kindFields"`
+	Recipients          []string   `docs:"The age public keys to encrypt the recipient kind fields for" json:"recipients,omitempty"          yaml:"recipients,omitempty"`
+	RecipientKindFields KindFields `docs:"The fields to encrypt for the age recipients for each kind"   json:"recipientKindFields,omitempty" yaml:"recipientKindFields,omitempty"`
+	key                 *aesKey
+	recipients          ageRecipients
+}
+
+// encrypter encrypts values for the aes key or the age recipients.
+type encrypter interface {
+	encrypt(value any) (string, error)
+	// encrypted returns true if the value is already encrypted by the encrypter
+	encrypted(value string) bool
 }
 
 func (e *Encrypted) Setup() (err error) {
+	if e.AesKey == "" && e.AesKeyFile != "" {
+		if e.AesKey, err = ReadKeyFile(e.AesKeyFile); err != nil {
+			return err
+		}
+	}
 	if k, ok := os.LookupEnv(EnvAesKey); ok {
 		e.AesKey = k
 	}
 	if e.AesKey != "" {
-		e.gcm, err = setupAES(e.AesKey)
+		e.key, err = newAESKey(e.AesKey)
 		if err != nil {
 			return err
 		}
-
-		e.nonce = make([]byte, e.gcm.NonceSize())
-
-		if _, err = io.ReadFull(rand.Reader, e.nonce); err != nil {
-			return err
-		}
 	} else if len(e.KindFields) > 0 {
 		return fmt.Errorf("encrypted mode needs a valid aesKey."+
 			" please remove the 'encrypted config' or provide the 'aesKey' in the config of via env variable %q",
 			EnvAesKey,
 		)
 	}
+	if e.recipients, err = parseAgeRecipients(e.Recipients...); err != nil {
+		return err
+	}
+	if len(e.recipients) == 0 && len(e.RecipientKindFields) > 0 {
+		return errors.New("the recipient kind fields need at least one age recipient")
+	}
 	return nil
 }
 
-func setupAES(key string) (cipher.AEAD, error) {
-	k := len(key)
-	switch k {
-	case 16, 24, 32:
-	default:
-		return nil, fmt.Errorf("invalid key size %d: aesKey must be 16, 24 or 32 chars long", k)
+// aesKey an AES-GCM key identified by the id embedded in the encrypted values.
+type aesKey struct {
+	id string
+	// header holds the kdf and salt of keys derived from a passphrase
+	header string
+	// gcm seals with a random nonce per value
+	gcm cipher.AEAD
+	// legacy opens values of the format without key id
+	legacy cipher.AEAD
+}
+
+// newAESKey creates the key of the key spec, keys of passphrases are derived with a new random salt.
+func newAESKey(key string) (*aesKey, error) {
+	spec, err := parseKeySpec(key)
+	if err != nil {
+		return nil, err
+	}
+	if spec.kdf == "" {
+		return keyFromBytes(spec.key, "")
+	}
+	header, _ := spec.newSalt()
+	derived, err := spec.derive(header)
+	if err != nil {
+		return nil, err
 	}
+	return keyFromBytes(derived, header)
+}
 
-	c, err := aes.NewCipher([]byte(key))
+func keyFromBytes(key []byte, header string) (*aesKey, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := cipher.NewGCM(block)
 	if err != nil {
 		return nil, err
 	}
 
-	gcm, err := cipher.NewGCM(c)
+	sum := sha256.Sum256(key)
+	return &aesKey{id: hex.EncodeToString(sum[:keyIDLength]), header: header, gcm: gcm, legacy: legacy}, nil
+}
+
+// encrypt the JSON encoding of the value, the key id and header are authenticated as additional data.
+func (k *aesKey) encrypt(value any) (string, error) {
+	plaintext, err := json.Marshal(value)
 	if err != nil {
+		return "", err
+	}
+	ciphertext := k.gcm.Seal(nil, nil, plaintext, additionalData(k.id, k.header))
+	return prefix + formatV3 + k.keyRef() + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// keyRef the key id and header of the encrypted values.
+func (k *aesKey) keyRef() string {
+	if k.header == "" {
+		return k.id + ":"
+	}
+	return k.id + ":" + k.header + ":"
+}
+
+// encrypted returns true if the value was encrypted by this key in the current format.
+func (k *aesKey) encrypted(value string) bool {
+	return strings.HasPrefix(value, prefix+formatV3+k.keyRef())
+}
+
+func additionalData(id, header string) []byte {
+	if header == "" {
+		return []byte(id)
+	}
+	return []byte(id + ":" + header)
+}
+
+// Keyring holds the aes keys, passphrases and age identities to decrypt values.
+type Keyring struct {
+	keys        []*aesKey
+	passphrases []*keySpec
+	identities  []age.Identity
+	// derived caches the keys derived from the passphrases per kdf header
+	derived map[string][]*aesKey
+}
+
+// NewKeyring creates a keyring of the given aes keys or passphrases, duplicates and empty keys are ignored.
+func NewKeyring(keys ...string) (*Keyring, error) {
+	kr := &Keyring{derived: make(map[string][]*aesKey)}
+	ids := newSet()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		spec, err := parseKeySpec(key)
+		if err != nil {
+			return nil, err
+		}
+		if spec.kdf != "" {
+			kr.passphrases = append(kr.passphrases, spec)
+			continue
+		}
+		k, err := keyFromBytes(spec.key, "")
+		if err != nil {
+			return nil, err
+		}
+		if !ids.contains(k.id) {
+			ids.add(k.id)
+			kr.keys = append(kr.keys, k)
+		}
+	}
+	return kr, nil
+}
+
+func (kr *Keyring) validate() error {
+	if len(kr.keys) == 0 && len(kr.passphrases) == 0 && len(kr.identities) == 0 {
+		return errors.New("at least one aes key, passphrase or age identity is required")
+	}
+	return nil
+}
+
+// decrypt the value and the encrypted values within the decrypted subtree.
+func (kr *Keyring) decrypt(value string) (any, error) {
+	v, err := kr.open(value)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := replaceEncrypted(v, kr.decrypt); err != nil {
 		return nil, err
 	}
+	return v, nil
+}
+
+// open the value. Values of the legacy format without key id are strings and opened with the first matching key.
+func (kr *Keyring) open(value string) (any, error) {
+	if strings.HasPrefix(value, agePrefix) {
+		return kr.openAge(value)
+	}
+	payload := strings.TrimPrefix(value, prefix)
+	if after, ok := strings.CutPrefix(payload, formatV3); ok {
+		id, data, ok := strings.Cut(after, ":")
+		if !ok {
+			return nil, errors.New("invalid encrypted value: missing key id")
+		}
+		var header string
+		if h, d, ok := strings.Cut(data, ":"); ok {
+			header, data = h, d
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		k, err := kr.key(id, header)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := k.gcm.Open(nil, nil, ciphertext, additionalData(id, header))
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		// util json keeps integers as int64 as expected by unstructured objects
+		if err := utiljson.Unmarshal(plaintext, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, err
+	}
+	err = errors.New("invalid text size")
+	for _, k := range kr.keys {
+		nonceSize := k.legacy.NonceSize()
+		if len(ciphertext) < nonceSize {
+			continue
+		}
+		var plaintext []byte
+		if plaintext, err = k.legacy.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], nil); err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return nil, err
+}
+
+// key returns the key with the id, keys with a kdf header are derived from the passphrases.
+func (kr *Keyring) key(id, header string) (*aesKey, error) {
+	keys := kr.keys
+	if header != "" {
+		var err error
+		if keys, err = kr.derive(header); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keys {
+		if k.id == id {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("no key with id %s in the keyring", id)
+}
 
-	return gcm, nil
+func (kr *Keyring) derive(header string) ([]*aesKey, error) {
+	if keys, ok := kr.derived[header]; ok {
+		return keys, nil
+	}
+	var keys []*aesKey
+	for _, p := range kr.passphrases {
+		derived, err := p.derive(header)
+		if err != nil {
+			return nil, err
+		}
+		k, err := keyFromBytes(derived, header)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	kr.derived[header] = keys
+	return keys, nil
 }
 
-func (e *Encrypted) doEncrypt(val any) string {
-	if e.AesKey == "" {
+// doEncrypt encrypts the value including its type with the aes key, objects and lists are encrypted as a whole.
+func (e *Encrypted) doEncrypt(val any) any {
+	if e.key == nil {
 		return ""
 	}
+	return encryptValue(e.key, val)
+}
 
-	// Convert to string
-	strVal := fmt.Sprintf("%v", val)
+// doEncryptForRecipients encrypts the value including its type for the age recipients.
+func (e *Encrypted) doEncryptForRecipients(val any) any {
+	return encryptValue(e.recipients, val)
+}
 
+func encryptValue(enc encrypter, val any) any {
 	// Don't encrypt if already encrypted or empty
-	if strings.HasPrefix(strVal, prefix) || strVal == "" {
-		return strVal
+	if s, ok := val.(string); ok && (isEncrypted(s) || s == "") {
+		return s
 	}
 
-	data := []byte(strVal)
-	return prefix + base64.StdEncoding.EncodeToString(e.gcm.Seal(e.nonce, e.nonce, data, nil))
+	v, err := enc.encrypt(val)
+	if err != nil {
+		// values of unstructured objects are always JSON compatible
+		return ""
+	}
+	return v
+}
+
+// isEncrypted returns true if the value is encrypted with an aes key or for age recipients.
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) || strings.HasPrefix(value, agePrefix)
 }
 
 // EncryptFields encrypts fields for a given resource.
 func (c *Config) EncryptFields(res *GroupResource, us unstructured.Unstructured) {
-	transformNestedFields(c.Encrypted.KindFields, c.Encrypted.doEncrypt, res.GroupKind(), us)
+	transformNestedFields(c.Encrypted.KindFields, c.Encrypted.doEncrypt, res, us)
+	transformNestedFields(c.Encrypted.RecipientKindFields, c.Encrypted.doEncryptForRecipients, res, us)
 }
 
-func Decrypt(printFlags *genericclioptions.PrintFlags, aesKey string, files ...string) error {
-	gcm, err := setupAES(aesKey)
+// DecryptOptions the options of decrypt, by default the files are decrypted in place.
+type DecryptOptions struct {
+	// ArchiveOut if set, the decrypted archive is written to this path.
+	ArchiveOut string
+	// Stdout print the decrypted objects instead of changing the files.
+	Stdout bool
+	// OutputDir write the decrypted files into this directory, mirroring the tree of the input.
+	OutputDir string
+	// Verify only check that every encrypted value can be decrypted, no files are changed.
+	Verify bool
+}
+
+func (o DecryptOptions) validate() error {
+	var modes int
+	for _, set := range []bool{o.ArchiveOut != "", o.Stdout, o.OutputDir != "", o.Verify} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return errors.New("only one of archive output, stdout, output dir and verify can be used")
+	}
+	return nil
+}
+
+// Decrypt decrypts the encrypted fields in the given files, directories or archives with the keys of the keyring.
+func Decrypt(printFlags *genericclioptions.PrintFlags, kr *Keyring, opts DecryptOptions, files ...string) error {
+	if err := kr.validate(); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if opts.Verify {
+		return verifyDecryption(printFlags, kr, files...)
+	}
+
+	p, err := printFlags.ToPrinter()
 	if err != nil {
 		return err
 	}
-	nonceSize := gcm.NonceSize()
 
 	table := render.Table()
 	table.Header("File", "Namespace", "Kind", "Name", "Decrypted Fields")
 
-	for _, file := range files {
-		us, err := utils.ReadFile(file)
+	err = utils.WalkFiles(extension(printFlags), opts.ArchiveOut, files, func(fsys vfs.FS, file string) error {
+		objs, err := utils.ReadAllFS(fsys, file)
 		if err != nil {
-			return err
+			return fmt.Errorf("error reading %q: %w", fsys.Path(file), err)
 		}
-		var replaced int
-		if replaced, err = decryptFields(us.Object, gcm, nonceSize); err != nil {
-			return err
-		}
-		if err := table.Append(
-			[]string{file, us.GetNamespace(), us.GetKind(), us.GetName(), strconv.Itoa(replaced)},
-		); err != nil {
-			return err
+		for _, us := range utils.ListItems(objs...) {
+			replaced, err := decryptFields(us.Object, kr)
+			if err != nil {
+				return fmt.Errorf("%s: %w", fsys.Path(file), err)
+			}
+			if err := table.Append(
+				[]string{fsys.Path(file), us.GetNamespace(), us.GetKind(), us.GetName(), strconv.Itoa(replaced)},
+			); err != nil {
+				return err
+			}
 		}
 
-		if err := utils.WriteFile(printFlags, file, us); err != nil {
-			return err
+		switch {
+		case opts.Stdout:
+			// one printer for all files, to separate all documents
+			for _, us := range objs {
+				if err := p.PrintObj(us, os.Stdout); err != nil {
+					return err
+				}
+			}
+			return nil
+		case opts.OutputDir != "":
+			name := outputName(file)
+			if err := os.MkdirAll(filepath.Join(opts.OutputDir, filepath.Dir(name)), os.ModePerm); err != nil {
+				return err
+			}
+			return utils.WriteAllFS(printFlags, vfs.Dir(opts.OutputDir), name, objs)
+		default:
+			return utils.WriteAllFS(printFlags, fsys, file, objs)
 		}
+	})
+	if err != nil {
+		return err
 	}
 
+	if opts.Stdout {
+		// the table would mix with the printed objects
+		return nil
+	}
 	return table.Render()
 }
 
-// Encrypt encrypts secrets in exported resource files.
-func Encrypt(printFlags *genericclioptions.PrintFlags, aesKey string, files ...string) error {
-	// Create a config with encryption settings for Secrets only
-	// TODO: it could read the config from the file for flexibility
-	config := &Config{
-		Encrypted: &Encrypted{
-			AesKey: aesKey,
-			KindFields: KindFields{
-				"Secret": {{"data"}, {"stringData"}},
-			},
-		},
+// outputName returns the path of the file within the output dir,
+// paths that are absolute or outside the working directory are reduced to the file name.
+func outputName(name string) string {
+	if !filepath.IsLocal(name) {
+		return filepath.Base(name)
+	}
+	return filepath.Clean(name)
+}
+
+// verifyDecryption decrypts all encrypted values without changing the files and reports the values that fail.
+func verifyDecryption(printFlags *genericclioptions.PrintFlags, kr *Keyring, files ...string) error {
+	table := render.Table()
+	table.Header("File", "Namespace", "Kind", "Name", "Field", "Error")
+
+	var values, failed int
+	err := utils.WalkFiles(extension(printFlags), "", files, func(fsys vfs.FS, file string) error {
+		objs, err := utils.ReadAllFS(fsys, file)
+		if err != nil {
+			return fmt.Errorf("error reading %q: %w", fsys.Path(file), err)
+		}
+		for _, us := range utils.ListItems(objs...) {
+			var rows [][]string
+			visitEncrypted(us.Object, "", func(field, value string) {
+				values++
+				if _, err := kr.decrypt(value); err != nil {
+					rows = append(rows, []string{
+						fsys.Path(file), us.GetNamespace(), us.GetKind(), us.GetName(), field, err.Error(),
+					})
+				}
+			})
+			slices.SortFunc(rows, func(a, b []string) int { return strings.Compare(a[4], b[4]) })
+			failed += len(rows)
+			for _, row := range rows {
+				if err := table.Append(row); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-	if err := config.Encrypted.Setup(); err != nil {
+
+	if failed == 0 {
+		_, _ = fmt.Printf("All %d encrypted values can be decrypted\n", values)
+		return nil
+	}
+	if err := table.Render(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d of %d encrypted values can not be decrypted", failed, values)
+}
+
+// visitEncrypted calls fn with the field path of each encrypted value within the node.
+func visitEncrypted(node any, path string, fn func(field, value string)) {
+	switch e := node.(type) {
+	case map[string]any:
+		for key, value := range e {
+			visitEncrypted(value, joinField(path, key), fn)
+		}
+	case []any:
+		for i, value := range e {
+			visitEncrypted(value, path+"["+strconv.Itoa(i)+"]", fn)
+		}
+	case string:
+		if isEncrypted(e) {
+			fn(path, e)
+		}
+	}
+}
+
+// RekeyTarget the new aes key or age recipients of rekey.
+type RekeyTarget struct {
+	AesKey     string
+	Recipients []string
+}
+
+func (t RekeyTarget) encrypter() (encrypter, error) {
+	if (t.AesKey == "") == (len(t.Recipients) == 0) {
+		return nil, errors.New("either a new aes key or age recipients are required")
+	}
+	if t.AesKey != "" {
+		return newAESKey(t.AesKey)
+	}
+	return parseAgeRecipients(t.Recipients...)
+}
+
+// Rekey re-encrypts the encrypted fields in the given files or archives with a new key or for new age recipients.
+// The values are decrypted with the keys of the keyring, values encrypted with the new aes key are kept.
+func Rekey(printFlags *genericclioptions.PrintFlags, kr *Keyring, to RekeyTarget, archiveOut string, files ...string) error {
+	if err := kr.validate(); err != nil {
+		return err
+	}
+	target, err := to.encrypter()
+	if err != nil {
 		return err
 	}
 
 	table := render.Table()
-	table.Header("File", "Namespace", "Kind", "Name", "Encrypted Fields")
+	table.Header("File", "Namespace", "Kind", "Name", "Rekeyed Fields")
 
-	for _, file := range files {
-		us, err := utils.ReadFile(file)
+	err = utils.WalkFiles(extension(printFlags), archiveOut, files, func(fsys vfs.FS, file string) error {
+		objs, err := utils.ReadAllFS(fsys, file)
 		if err != nil {
-			return err
+			return fmt.Errorf("error reading %q: %w", fsys.Path(file), err)
+		}
+		for _, us := range utils.ListItems(objs...) {
+			replaced, err := replaceEncrypted(us.Object, func(value string) (any, error) {
+				if target.encrypted(value) {
+					return value, nil
+				}
+				v, err := kr.decrypt(value)
+				if err != nil {
+					return nil, err
+				}
+				return target.encrypt(v)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", fsys.Path(file), err)
+			}
+			if err := table.Append(
+				[]string{fsys.Path(file), us.GetNamespace(), us.GetKind(), us.GetName(), strconv.Itoa(replaced)},
+			); err != nil {
+				return err
+			}
 		}
 
-		res := &GroupResource{
-			APIResource: metav1.APIResource{
-				Kind: us.GetKind(),
-			},
+		return utils.WriteAllFS(printFlags, fsys, file, objs)
+	})
+	if err != nil {
+		return err
+	}
+
+	return table.Render()
+}
+
+// Encrypt encrypts the fields of the encrypted kind fields of the config in the given files, directories or archives.
+// With check, no files are changed, but an error is returned if any of these fields contains plaintext.
+// Category rules are rejected, as the discovery categories of the kinds are not known from the files.
+func Encrypt(config *Config, check bool, archiveOut string, files ...string) error {
+	rules := append(config.Encrypted.KindFields.categoryRules(), config.Encrypted.RecipientKindFields.categoryRules()...)
+	if len(rules) > 0 {
+		return fmt.Errorf("category rules are not supported for exported files: %s", strings.Join(rules, ", "))
+	}
+
+	table := render.Table()
+	if check {
+		table.Header("File", "Namespace", "Kind", "Name", "Plaintext Fields")
+	} else {
+		table.Header("File", "Namespace", "Kind", "Name", "Encrypted Fields")
+	}
+
+	var plaintext int
+	err := utils.WalkFiles(extension(config.PrintFlags), archiveOut, files, func(fsys vfs.FS, file string) error {
+		objs, err := utils.ReadAllFS(fsys, file)
+		if err != nil {
+			return fmt.Errorf("error reading %q: %w", fsys.Path(file), err)
 		}
-		config.EncryptFields(res, *us)
-		encryptedCount := countEncryptedFields(us.Object)
 
-		if err := table.Append(
-			[]string{file, us.GetNamespace(), us.GetKind(), us.GetName(), strconv.Itoa(encryptedCount)},
-		); err != nil {
-			return err
+		for _, us := range utils.ListItems(objs...) {
+			res := groupResourceOf(us)
+			var cnt int
+			if check {
+				if cnt = config.PlaintextFields(res, *us); cnt == 0 {
+					continue
+				}
+				plaintext += cnt
+			} else {
+				config.EncryptFields(res, *us)
+				cnt = countEncryptedFields(us.Object)
+			}
+			if err := table.Append(
+				[]string{fsys.Path(file), us.GetNamespace(), us.GetKind(), us.GetName(), strconv.Itoa(cnt)},
+			); err != nil {
+				return err
+			}
 		}
 
-		if err := utils.WriteFile(printFlags, file, us); err != nil {
-			return err
+		if check {
+			return nil
 		}
+		return utils.WriteAllFS(config.PrintFlags, fsys, file, objs)
+	})
+	if err != nil {
+		return err
 	}
 
-	return table.Render()
+	if check && plaintext == 0 {
+		_, _ = fmt.Println("No plaintext found in fields to encrypt")
+		return nil
+	}
+	if err := table.Render(); err != nil {
+		return err
+	}
+	if plaintext > 0 {
+		return fmt.Errorf("found %d plaintext value(s) in fields to encrypt", plaintext)
+	}
+	return nil
+}
+
+// PlaintextFields returns the number of values of the fields to encrypt for the resource that are not encrypted.
+func (c *Config) PlaintextFields(res *GroupResource, us unstructured.Unstructured) int {
+	var cnt int
+	count := func(val any) any {
+		if s, ok := val.(string); !ok || (s != "" && !isEncrypted(s)) {
+			cnt++
+		}
+		return val
+	}
+	transformNestedFields(c.Encrypted.KindFields, count, res, us)
+	transformNestedFields(c.Encrypted.RecipientKindFields, count, res, us)
+	return cnt
+}
+
+// groupResourceOf returns the group resource of the object's apiVersion and kind, without discovery categories.
+func groupResourceOf(us *unstructured.Unstructured) *GroupResource {
+	gvk := us.GroupVersionKind()
+	return &GroupResource{
+		APIGroup:        gvk.Group,
+		APIVersion:      gvk.Version,
+		APIGroupVersion: us.GetAPIVersion(),
+		APIResource: metav1.APIResource{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
+		},
+	}
+}
+
+func extension(printFlags *genericclioptions.PrintFlags) string {
+	if printFlags != nil && printFlags.OutputFormat != nil {
+		return "." + *printFlags.OutputFormat
+	}
+	return "." + DefaultFormat
 }
 
-// transformNestedField transforms the nested field from the obj.
-func decryptFields(obj map[string]any, gcm cipher.AEAD, nonceSize int) (int, error) {
+// decryptFields decrypts all encrypted values of the obj.
+func decryptFields(obj map[string]any, kr *Keyring) (int, error) {
+	return replaceEncrypted(obj, kr.decrypt)
+}
+
+// replaceEncrypted replaces all encrypted values within the node, including list items, with the result of replace.
+func replaceEncrypted(node any, replace func(value string) (any, error)) (int, error) {
 	var replaced int
-	for key, value := range obj {
-		switch e := value.(type) {
-		case map[string]any:
-			var cnt int
-			var err error
-			if cnt, err = decryptFields(e, gcm, nonceSize); err != nil {
-				return 0, err
-			}
+	visit := func(value any, set func(any)) error {
+		s, ok := value.(string)
+		if !ok {
+			cnt, err := replaceEncrypted(value, replace)
 			replaced += cnt
-		case string:
-			if after, ok := strings.CutPrefix(e, prefix); ok {
-				ciphertext, err := base64.StdEncoding.DecodeString(after)
-				if err != nil {
-					return 0, err
-				}
+			return err
+		}
+		if !isEncrypted(s) {
+			return nil
+		}
+		r, err := replace(s)
+		if err != nil {
+			return err
+		}
+		if r != s {
+			set(r)
+			replaced++
+		}
+		return nil
+	}
 
-				if len(ciphertext) < nonceSize {
-					return 0, errors.New("invalid text size")
-				}
-				nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-				plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-				if err != nil {
-					return 0, err
-				}
-				obj[key] = string(plaintext)
-				replaced++
+	switch e := node.(type) {
+	case map[string]any:
+		for key, value := range e {
+			if err := visit(value, func(v any) { e[key] = v }); err != nil {
+				return 0, err
+			}
+		}
+	case []any:
+		for i, value := range e {
+			if err := visit(value, func(v any) { e[i] = v }); err != nil {
+				return 0, err
 			}
 		}
 	}
@@ -215,16 +727,20 @@ func decryptFields(obj map[string]any, gcm cipher.AEAD, nonceSize int) (int, err
 }
 
 // countEncryptedFields counts the number of fields that have been encrypted.
-func countEncryptedFields(obj map[string]any) int {
+func countEncryptedFields(node any) int {
 	var count int
-	for _, value := range obj {
-		switch e := value.(type) {
-		case map[string]any:
-			count += countEncryptedFields(e)
-		case string:
-			if strings.HasPrefix(e, prefix) {
-				count++
-			}
+	switch e := node.(type) {
+	case map[string]any:
+		for _, value := range e {
+			count += countEncryptedFields(value)
+		}
+	case []any:
+		for _, value := range e {
+			count += countEncryptedFields(value)
+		}
+	case string:
+		if isEncrypted(e) {
+			count++
 		}
 	}
 	return count
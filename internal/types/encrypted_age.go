@@ -0,0 +1,99 @@
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// agePrefix marks values encrypted for age recipients: KUBEXPORTER_AGE@<base64 age ciphertext>.
+// The plaintext is the JSON encoding of the value to preserve its type.
+const agePrefix = "KUBEXPORTER_AGE@"
+
+// ageRecipients encrypt values for age (X25519) recipients, only holders of a private key can decrypt them.
+type ageRecipients []age.Recipient
+
+func parseAgeRecipients(recipients ...string) (ageRecipients, error) {
+	var ar ageRecipients
+	for _, r := range recipients {
+		parsed, err := age.ParseRecipients(strings.NewReader(r))
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", r, err)
+		}
+		ar = append(ar, parsed...)
+	}
+	return ar, nil
+}
+
+func (ar ageRecipients) encrypt(value any) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, ar...)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return agePrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// encrypted returns false, as the recipients of a value can't be determined and values are always re-encrypted.
+func (ageRecipients) encrypted(string) bool {
+	return false
+}
+
+// AddAgeIdentities adds the age identities (private keys) of the given files to the keyring.
+func (kr *Keyring) AddAgeIdentities(files ...string) error {
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		ids, err := age.ParseIdentities(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("invalid age identity file %q: %w", file, err)
+		}
+		kr.identities = append(kr.identities, ids...)
+	}
+	return nil
+}
+
+func (kr Keyring) openAge(value string) (any, error) {
+	if len(kr.identities) == 0 {
+		return nil, errors.New("an age identity is required to decrypt age encrypted values")
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, agePrefix))
+	if err != nil {
+		return nil, err
+	}
+	r, err := age.Decrypt(bytes.NewReader(ciphertext), kr.identities...)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	// util json keeps integers as int64 as expected by unstructured objects
+	if err := utiljson.Unmarshal(plaintext, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
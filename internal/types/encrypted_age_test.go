@@ -0,0 +1,145 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func ageIdentity(t *testing.T) (*age.X25519Identity, string) {
+	t.Helper()
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "identity.txt")
+	if err := os.WriteFile(file, []byte(id.String()+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return id, file
+}
+
+func TestEncrypted_Setup_recipients(t *testing.T) {
+	id, _ := ageIdentity(t)
+	for _, tt := range []struct {
+		name    string
+		enc     *Encrypted
+		wantErr bool
+	}{
+		{
+			name: "recipients without aes key should be ok",
+			enc: &Encrypted{
+				Recipients:          []string{id.Recipient().String()},
+				RecipientKindFields: KindFields{"Secret": {{"data"}}},
+			},
+		},
+		{
+			name:    "fail if recipient kind fields are set without recipients",
+			enc:     &Encrypted{RecipientKindFields: KindFields{"Secret": {{"data"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "fail on invalid recipients",
+			enc:     &Encrypted{Recipients: []string{"age1invalid"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.enc.Setup(); (err != nil) != tt.wantErr {
+				t.Errorf("Encrypted.Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptFields_recipients(t *testing.T) {
+	id, identityFile := ageIdentity(t)
+	enc := &Encrypted{
+		AesKey:              "1234567890123456",
+		KindFields:          KindFields{"Secret": {{"stringData"}}},
+		Recipients:          []string{id.Recipient().String()},
+		RecipientKindFields: KindFields{"Secret": {{"data"}}},
+	}
+	if err := enc.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Encrypted: enc}
+
+	data := func() map[string]any {
+		return map[string]any{
+			"data":       map[string]any{"password": "cGFzc3dvcmQ=", "count": int64(2)},
+			"stringData": map[string]any{"token": "token"},
+		}
+	}
+	us := unstructured.Unstructured{Object: data()}
+	config.EncryptFields(&GroupResource{APIResource: metav1.APIResource{Kind: "Secret"}}, us)
+
+	password, _, _ := unstructured.NestedString(us.Object, "data", "password")
+	token, _, _ := unstructured.NestedString(us.Object, "stringData", "token")
+	if !strings.HasPrefix(password, agePrefix) {
+		t.Errorf("expected data to be encrypted for the recipient, got %q", password)
+	}
+	if !strings.HasPrefix(token, prefix) {
+		t.Errorf("expected stringData to be encrypted with the aes key, got %q", token)
+	}
+
+	aesOnly := keyring(t, "1234567890123456")
+	if _, err := decryptFields(us.DeepCopy().Object, aesOnly); err == nil {
+		t.Error("expected the age values not to be decryptable without identity")
+	}
+
+	kr := keyring(t, "1234567890123456")
+	if err := kr.AddAgeIdentities(identityFile); err != nil {
+		t.Fatal(err)
+	}
+	cnt, err := decryptFields(us.Object, kr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 3 {
+		t.Errorf("expected 3 decrypted fields, got %d", cnt)
+	}
+	if !reflect.DeepEqual(us.Object, data()) {
+		t.Errorf("expected %v, got %v", data(), us.Object)
+	}
+}
+
+func TestRekey_recipients(t *testing.T) {
+	id, identityFile := ageIdentity(t)
+	key, _ := newAESKey("1234567890123456")
+	file := filepath.Join(t.TempDir(), "secret.yaml")
+	content := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n" +
+		"  a: " + mustEncrypt(t, key, "a") + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	to := RekeyTarget{Recipients: []string{id.Recipient().String()}}
+	if err := Rekey(printFlags(), keyring(t, "1234567890123456"), to, "", file); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), agePrefix) {
+		t.Fatalf("expected the value to be encrypted for the recipient, got %s", b)
+	}
+
+	kr := keyring(t)
+	if err := kr.AddAgeIdentities(identityFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := Decrypt(printFlags(), kr, DecryptOptions{}, file); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ = os.ReadFile(file); !strings.Contains(string(b), "a: a") {
+		t.Errorf("expected the value to be decrypted, got %s", b)
+	}
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// key spec prefixes, keys without prefix are used as raw key of 16, 24 or 32 chars.
+	keySpecBase64     = "base64:"
+	keySpecHex        = "hex:"
+	keySpecPassphrase = "passphrase:"
+
+	kdfScrypt   = "scrypt"
+	kdfArgon2id = "argon2id"
+
+	kdfSaltLength = 16
+	kdfKeyLength  = 32
+
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	argon2Time    = 1
+	argon2Memory  = 64 * 1024
+	argon2Threads = 4
+)
+
+// kdfParameters the supported parameter sets per kdf, the first one is used for new values.
+// Other parameter sets are rejected, as they could be chosen to exhaust cpu or memory.
+var kdfParameters = map[string][]string{
+	kdfScrypt:   {fmt.Sprintf("N=%d,r=%d,p=%d", scryptN, scryptR, scryptP)},
+	kdfArgon2id: {fmt.Sprintf("t=%d,m=%d,p=%d", argon2Time, argon2Memory, argon2Threads)},
+}
+
+// keySpec an aes key given as raw, base64 or hex key, or a passphrase to derive the key from.
+type keySpec struct {
+	key []byte
+	// kdf is set for passphrases
+	kdf        string
+	passphrase string
+}
+
+// parseKeySpec parses the key, supported are:
+// raw keys of 16, 24 or 32 chars, 'base64:<key>', 'hex:<key>' and passphrases 'scrypt:<passphrase>',
+// 'argon2id:<passphrase>' or 'passphrase:<passphrase>' (scrypt).
+func parseKeySpec(spec string) (*keySpec, error) {
+	var key []byte
+	switch {
+	case strings.HasPrefix(spec, keySpecBase64):
+		b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(spec, keySpecBase64))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 aes key: %w", err)
+		}
+		key = b
+	case strings.HasPrefix(spec, keySpecHex):
+		b, err := hex.DecodeString(strings.TrimPrefix(spec, keySpecHex))
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex aes key: %w", err)
+		}
+		key = b
+	case strings.HasPrefix(spec, keySpecPassphrase):
+		return passphraseSpec(kdfScrypt, strings.TrimPrefix(spec, keySpecPassphrase))
+	case strings.HasPrefix(spec, kdfScrypt+":"):
+		return passphraseSpec(kdfScrypt, strings.TrimPrefix(spec, kdfScrypt+":"))
+	case strings.HasPrefix(spec, kdfArgon2id+":"):
+		return passphraseSpec(kdfArgon2id, strings.TrimPrefix(spec, kdfArgon2id+":"))
+	default:
+		key = []byte(spec)
+	}
+
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("invalid key size %d: aesKey must be 16, 24 or 32 bytes long, "+
+			"or a passphrase prefixed with '%s', '%s:' or '%s:'", len(key), keySpecPassphrase, kdfScrypt, kdfArgon2id)
+	}
+	return &keySpec{key: key}, nil
+}
+
+func passphraseSpec(kdf, passphrase string) (*keySpec, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("the %s passphrase must not be empty", kdf)
+	}
+	return &keySpec{kdf: kdf, passphrase: passphrase}, nil
+}
+
+// newSalt creates a random salt and the kdf header of the encrypted values:
+// <kdf>$<parameters>$<base64 salt> e.g. scrypt$N=32768,r=8,p=1$<salt>.
+func (s *keySpec) newSalt() (string, []byte) {
+	salt := make([]byte, kdfSaltLength)
+	// crypto/rand never returns an error
+	_, _ = rand.Read(salt)
+	return strings.Join([]string{s.kdf, kdfParameters[s.kdf][0], base64.RawStdEncoding.EncodeToString(salt)}, "$"), salt
+}
+
+// derive the key from the passphrase with the kdf, parameters and salt of the header.
+func (s *keySpec) derive(header string) ([]byte, error) {
+	parts := strings.Split(header, "$")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid kdf header %q", header)
+	}
+	kdf, params, encodedSalt := parts[0], parts[1], parts[2]
+	supported, ok := kdfParameters[kdf]
+	if !ok {
+		return nil, fmt.Errorf("unsupported kdf %q", kdf)
+	}
+	if !slices.Contains(supported, params) {
+		return nil, fmt.Errorf("unsupported %s parameters %q", kdf, params)
+	}
+	p, err := parseKDFParameters(params)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kdf salt: %w", err)
+	}
+	if kdf == kdfScrypt {
+		return scrypt.Key([]byte(s.passphrase), salt, p["N"], p["r"], p["p"], kdfKeyLength)
+	}
+	//nolint:gosec // only the supported parameter sets are accepted
+	return argon2.IDKey([]byte(s.passphrase), salt, uint32(p["t"]), uint32(p["m"]), uint8(p["p"]), kdfKeyLength), nil
+}
+
+// parseKDFParameters parses the comma separated parameters of a kdf header e.g. 'N=32768,r=8,p=1'.
+func parseKDFParameters(params string) (map[string]int, error) {
+	values := make(map[string]int)
+	for param := range strings.SplitSeq(params, ",") {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid kdf parameter %q", param)
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kdf parameter %q: %w", param, err)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// ReadKeyFile reads a key from a file, trailing line breaks are removed.
+func ReadKeyFile(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
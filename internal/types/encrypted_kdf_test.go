@@ -0,0 +1,129 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseKeySpec(t *testing.T) {
+	raw, err := newAESKey("1234567890123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, spec := range []string{
+		"base64:MTIzNDU2Nzg5MDEyMzQ1Ng==",
+		"hex:31323334353637383930313233343536",
+	} {
+		k, err := newAESKey(spec)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", spec, err)
+		}
+		if k.id != raw.id {
+			t.Errorf("expected %q to be the same key as the raw key", spec)
+		}
+	}
+
+	for _, invalid := range []string{
+		"short",
+		"base64:!!!",
+		"base64:c2hvcnQ=",
+		"hex:zz",
+		"scrypt:",
+		"passphrase:",
+	} {
+		if _, err := parseKeySpec(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestEncryptFields_passphrase(t *testing.T) {
+	for spec, kdf := range map[string]string{
+		"passphrase:correct horse": "scrypt$N=32768,r=8,p=1$",
+		"argon2id:correct horse":   "argon2id$t=1,m=65536,p=4$",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			enc := &Encrypted{AesKey: spec, KindFields: KindFields{"Secret": {{"data"}}}}
+			if err := enc.Setup(); err != nil {
+				t.Fatal(err)
+			}
+			config := &Config{Encrypted: enc}
+			us := unstructured.Unstructured{Object: map[string]any{
+				"data": map[string]any{"a": "A", "b": "B"},
+			}}
+			config.EncryptFields(&GroupResource{APIResource: metav1.APIResource{Kind: "Secret"}}, us)
+
+			a, _, _ := unstructured.NestedString(us.Object, "data", "a")
+			if !strings.HasPrefix(a, prefix+formatV3+enc.key.id+":"+kdf) {
+				t.Errorf("expected the kdf, parameters and salt in the header, got %q", a)
+			}
+
+			// the kdf is taken from the header
+			cnt, err := decryptFields(us.Object, keyring(t, "scrypt:wrong", "scrypt:correct horse"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cnt != 2 {
+				t.Errorf("expected 2 decrypted fields, got %d", cnt)
+			}
+			if b, _, _ := unstructured.NestedString(us.Object, "data", "b"); b != "B" {
+				t.Errorf("expected B, got %q", b)
+			}
+		})
+	}
+
+	t.Run("should fail with a wrong passphrase", func(t *testing.T) {
+		k, err := newAESKey("passphrase:correct horse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := keyring(t, "passphrase:wrong").decrypt(mustEncrypt(t, k, "a")); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestKeySpec_derive(t *testing.T) {
+	spec, err := parseKeySpec("passphrase:correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := spec.newSalt()
+	if _, err := spec.derive(header); err != nil {
+		t.Errorf("expected the header %q to be valid: %v", header, err)
+	}
+	salt := strings.Split(header, "$")[2]
+	for _, invalid := range []string{
+		"scrypt=" + salt,
+		"scrypt$" + salt,
+		"scrypt$N=1048576,r=8,p=1$" + salt,
+		"scrypt$r=8,p=1,N=32768$" + salt,
+		"argon2id$t=1,m=4194304,p=4$" + salt,
+		"bcrypt$N=32768,r=8,p=1$" + salt,
+		"scrypt$N=32768,r=8,p=1$!!!",
+	} {
+		if _, err := spec.derive(invalid); err == nil {
+			t.Errorf("expected the header %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestEncrypted_Setup_keyFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(file, []byte("hex:31323334353637383930313233343536\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	enc := &Encrypted{AesKeyFile: file, KindFields: KindFields{"Secret": {{"data"}}}}
+	if err := enc.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := newAESKey("1234567890123456")
+	if enc.key == nil || enc.key.id != raw.id {
+		t.Errorf("expected the key of the file to be used")
+	}
+}
@@ -1,11 +1,15 @@
 package types
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 func TestEncrypted_Setup(t *testing.T) {
@@ -57,19 +61,11 @@ func TestEncrypted_Setup(t *testing.T) {
 				return
 			}
 			if !tt.wantErr {
-				if enc.gcm == nil {
-					t.Error("Encrypted.gcm is nil")
-				}
-				if enc.nonce == nil {
-					t.Error("Encrypted.nonce is nil")
-				}
-			} else {
-				if enc.gcm != nil {
-					t.Error("Encrypted.gcm is not nil")
-				}
-				if enc.nonce != nil {
-					t.Error("Encrypted.nonce is not nil")
+				if enc.key == nil {
+					t.Error("Encrypted.key is nil")
 				}
+			} else if enc.key != nil {
+				t.Error("Encrypted.key is not nil")
 			}
 		})
 	}
@@ -185,7 +181,7 @@ func TestDecryptFields(t *testing.T) {
 					"secret": tt.input,
 				},
 			}}
-			cnt, err := decryptFields(us.Object, enc.gcm, len(enc.nonce))
+			cnt, err := decryptFields(us.Object, &Keyring{keys: []*aesKey{enc.key}})
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -199,3 +195,284 @@ func TestDecryptFields(t *testing.T) {
 		})
 	}
 }
+
+func TestKeyring(t *testing.T) {
+	oldKey, err := newAESKey("1234567890123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := newAESKey("abcdefghijklmnopqrstuvwxyz012345")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("should use a random nonce per value", func(t *testing.T) {
+		if mustEncrypt(t, oldKey, "value") == mustEncrypt(t, oldKey, "value") {
+			t.Error("expected different ciphertexts for the same value")
+		}
+	})
+
+	t.Run("should embed the key id", func(t *testing.T) {
+		v := mustEncrypt(t, newKey, "value")
+		if !strings.HasPrefix(v, prefix+formatV3+newKey.id+":") {
+			t.Errorf("expected key id %s in %q", newKey.id, v)
+		}
+	})
+
+	t.Run("should decrypt with the matching key of the keyring", func(t *testing.T) {
+		kr, err := NewKeyring("1234567890123456", "abcdefghijklmnopqrstuvwxyz012345")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []string{
+			mustEncrypt(t, oldKey, "old"),
+			mustEncrypt(t, newKey, "new"),
+			// legacy format without key id
+			"KUBEXPORTER_AES@wKCCGma3NhnvzLMbMCrPK7nq7cQV6hF385YuqLjSk+UXCRgaQATO3PPUsfoheg==",
+		} {
+			if _, err := kr.decrypt(v); err != nil {
+				t.Errorf("unexpected error decrypting %q: %v", v, err)
+			}
+		}
+	})
+
+	t.Run("should fail if the key id is unknown", func(t *testing.T) {
+		kr := &Keyring{keys: []*aesKey{oldKey}}
+		if _, err := kr.decrypt(mustEncrypt(t, newKey, "new")); err == nil ||
+			!strings.Contains(err.Error(), "no key with id "+newKey.id) {
+			t.Errorf("expected unknown key id error, got %v", err)
+		}
+	})
+
+	t.Run("should fail if the key id was tampered with", func(t *testing.T) {
+		kr := &Keyring{keys: []*aesKey{oldKey, newKey}}
+		v := strings.Replace(mustEncrypt(t, oldKey, "old"), oldKey.id, newKey.id, 1)
+		if _, err := kr.decrypt(v); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestRekey(t *testing.T) {
+	oldKey, _ := newAESKey("1234567890123456")
+	file := filepath.Join(t.TempDir(), "secret.yaml")
+	content := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n" +
+		"  legacy: KUBEXPORTER_AES@wKCCGma3NhnvzLMbMCrPK7nq7cQV6hF385YuqLjSk+UXCRgaQATO3PPUsfoheg==\n" +
+		"  v3: " + mustEncrypt(t, oldKey, int64(3)) + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pf := printFlags()
+	newKey := "abcdefghijklmnopqrstuvwxyz012345"
+	if err := Rekey(pf, keyring(t, "1234567890123456"), RekeyTarget{AesKey: newKey}, "", file); err != nil {
+		t.Fatal(err)
+	}
+	if err := Decrypt(pf, keyring(t, "1234567890123456"), DecryptOptions{}, file); err == nil {
+		t.Fatal("expected the old key to fail")
+	}
+	if err := Decrypt(pf, keyring(t, newKey), DecryptOptions{}, file); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"legacy: don't tell anyone!", "v3: 3"} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("expected %q in %s", s, b)
+		}
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "apps"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	list := "apiVersion: v1\nkind: List\nitems:\n" +
+		"- apiVersion: apps/v1\n  kind: Deployment\n  metadata:\n    name: d\n" +
+		"  spec:\n    token: apps-token\n    replicas: 3\n" +
+		"- apiVersion: example.com/v1\n  kind: Deployment\n  metadata:\n    name: other\n" +
+		"  spec:\n    token: other-token\n"
+	files := map[string]string{
+		filepath.Join(dir, "apps", "Deployment.yaml"): list,
+		filepath.Join(dir, "Secret.s.yaml"): "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n" +
+			"data:\n  password: cGFzc3dvcmQ=\n",
+		filepath.Join(dir, "ignored.json"): `{"apiVersion": "apps/v1", "kind": "Deployment"}`,
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &Config{
+		PrintFlags: printFlags(),
+		Encrypted: &Encrypted{
+			AesKey:     "1234567890123456",
+			KindFields: KindFields{"apps.Deployment": {{"spec", "token"}, {"spec", "replicas"}}},
+		},
+	}
+	if err := config.Encrypted.Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Encrypt(config, true, "", dir); err == nil {
+		t.Fatal("expected check to fail on plaintext")
+	}
+	if err := Encrypt(config, false, "", dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := Encrypt(config, true, "", dir); err != nil {
+		t.Fatalf("expected check to pass after encryption: %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "apps", "Deployment.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "apps-token") || strings.Count(string(b), prefix) != 2 {
+		t.Errorf("expected the apps Deployment to be encrypted: %s", b)
+	}
+	if !strings.Contains(string(b), "other-token") {
+		t.Errorf("expected the Deployment of the other group to stay plaintext: %s", b)
+	}
+	if b, _ := os.ReadFile(filepath.Join(dir, "Secret.s.yaml")); strings.Contains(string(b), prefix) {
+		t.Errorf("expected the Secret not to be encrypted: %s", b)
+	}
+
+	config.Encrypted.KindFields["category:all"] = [][]string{{"spec", "token"}}
+	if err := Encrypt(config, true, "", dir); err == nil || !strings.Contains(err.Error(), "category:all") {
+		t.Errorf("expected category rules to be rejected, got %v", err)
+	}
+}
+
+func TestDecrypt_options(t *testing.T) {
+	k, _ := newAESKey("1234567890123456")
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "ns"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "ns", "Secret.yaml")
+	content := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: a\ndata:\n  a: " + mustEncrypt(t, k, "value-a") + "\n" +
+		"---\napiVersion: v1\nkind: List\nitems:\n" +
+		"- apiVersion: v1\n  kind: Secret\n  metadata:\n    name: b\n  data:\n    b: " + mustEncrypt(t, k, "value-b") + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pf := printFlags()
+
+	t.Run("verify", func(t *testing.T) {
+		if err := Decrypt(pf, keyring(t, "1234567890123456"), DecryptOptions{Verify: true}, dir); err != nil {
+			t.Fatal(err)
+		}
+		err := Decrypt(pf, keyring(t, "abcdefghijklmnop"), DecryptOptions{Verify: true}, dir)
+		if err == nil || !strings.Contains(err.Error(), "2 of 2") {
+			t.Fatalf("expected 2 failed values, got %v", err)
+		}
+	})
+
+	t.Run("output dir", func(t *testing.T) {
+		out := t.TempDir()
+		if err := Decrypt(pf, keyring(t, "1234567890123456"), DecryptOptions{OutputDir: out}, dir); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(out, "ns", "Secret.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"a: value-a", "b: value-b", "---"} {
+			if !strings.Contains(string(b), s) {
+				t.Errorf("expected %q in %s", s, b)
+			}
+		}
+		if b, _ := os.ReadFile(file); string(b) != content {
+			t.Errorf("expected the input file to be unchanged: %s", b)
+		}
+	})
+
+	t.Run("conflicting options", func(t *testing.T) {
+		opts := DecryptOptions{Stdout: true, Verify: true}
+		if err := Decrypt(pf, keyring(t, "1234567890123456"), opts, dir); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func mustEncrypt(t *testing.T, k *aesKey, value any) string {
+	t.Helper()
+	v, err := k.encrypt(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestEncryptFields_types(t *testing.T) {
+	enc := &Encrypted{
+		AesKey: "1234567890123456",
+		KindFields: KindFields{"Widget": {
+			{"spec", "replicas"},
+			{"spec", "enabled"},
+			{"spec", "config"},
+			{"spec", "args"},
+			{`$.spec.env[?name=="TOKEN"]`},
+		}},
+	}
+	if err := enc.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{Encrypted: enc}
+
+	spec := func() map[string]any {
+		return map[string]any{
+			"replicas": int64(3),
+			"enabled":  true,
+			"config": map[string]any{
+				"ratio":  1.5,
+				"nested": map[string]any{"list": []any{"a", int64(1)}},
+			},
+			"args": []any{"--debug", "--port=80"},
+			"env": []any{
+				map[string]any{"name": "USER", "value": "user"},
+				map[string]any{"name": "TOKEN", "value": "secret"},
+			},
+			"plain": "value",
+		}
+	}
+	us := unstructured.Unstructured{Object: map[string]any{"spec": spec()}}
+	config.EncryptFields(&GroupResource{APIResource: metav1.APIResource{Kind: "Widget"}}, us)
+
+	// replicas, enabled, config.ratio, config.nested.list[0-1], args[0-1], env[1].name, env[1].value
+	if cnt := countEncryptedFields(us.Object); cnt != 9 {
+		t.Errorf("expected 9 encrypted fields, got %d: %v", cnt, us.Object)
+	}
+
+	cnt, err := decryptFields(us.Object, &Keyring{keys: []*aesKey{enc.key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 9 {
+		t.Errorf("expected 9 decrypted fields, got %d", cnt)
+	}
+	if !reflect.DeepEqual(us.Object["spec"], spec()) {
+		t.Errorf("expected the types to be restored, got %#v", us.Object["spec"])
+	}
+}
+
+func keyring(t *testing.T, keys ...string) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func printFlags() *genericclioptions.PrintFlags {
+	return &genericclioptions.PrintFlags{
+		OutputFormat:       new(DefaultFormat),
+		JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
+	}
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const celObjectVariable = "object"
+
+var (
+	celEnv     *cel.Env
+	celEnvErr  error
+	celEnvOnce sync.Once
+
+	// compiled programs are immutable and therefore shared.
+	celPrograms sync.Map
+)
+
+type celProgram struct {
+	program cel.Program
+	err     error
+}
+
+func env() (*cel.Env, error) {
+	celEnvOnce.Do(func() {
+		celEnv, celEnvErr = cel.NewEnv(
+			cel.Variable(celObjectVariable, cel.DynType),
+			ext.Strings(),
+		)
+	})
+	return celEnv, celEnvErr
+}
+
+// compileExpression compiles the CEL expression once, the expression must evaluate to a bool.
+func compileExpression(expr string) (cel.Program, error) {
+	if p, ok := celPrograms.Load(expr); ok {
+		//nolint:forcetypeassert
+		cp := p.(*celProgram)
+		return cp.program, cp.err
+	}
+
+	cp := &celProgram{}
+	cp.program, cp.err = doCompileExpression(expr)
+	celPrograms.Store(expr, cp)
+	return cp.program, cp.err
+}
+
+func doCompileExpression(expr string) (cel.Program, error) {
+	e, err := env()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := e.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("expression must evaluate to bool, but evaluates to %s", ast.OutputType())
+	}
+	return e.Program(ast)
+}
+
+// evaluate the expression against the object, errors during evaluation (e.g. missing fields) do not match.
+func evaluate(expr string, us unstructured.Unstructured) bool {
+	prg, err := compileExpression(expr)
+	if err != nil {
+		return false
+	}
+	out, _, err := prg.Eval(map[string]any{celObjectVariable: us.Object})
+	if err != nil {
+		return false
+	}
+	b, ok := out.Value().(bool)
+	return ok && b
+}
+
+// excludedByExpression returns the expression that excludes the instance.
+// If included expressions match the kind, instances not matching any of them are excluded.
+func (c *Config) excludedByExpression(res *GroupResource, us unstructured.Unstructured) string {
+	for _, key := range matchingKindKeys(c.Excluded.Expressions, res) {
+		for _, expr := range c.Excluded.Expressions[key] {
+			if evaluate(expr, us) {
+				return "expression " + expr
+			}
+		}
+	}
+
+	keys := matchingKindKeys(c.Included.Expressions, res)
+	if len(keys) == 0 {
+		return ""
+	}
+	for _, key := range keys {
+		for _, expr := range c.Included.Expressions[key] {
+			if evaluate(expr, us) {
+				return ""
+			}
+		}
+	}
+	return "not included by expression"
+}
+
+func (c *Config) validateExpressions() error {
+	for name, exprs := range map[string]map[string][]string{
+		"excluded": c.Excluded.Expressions,
+		"included": c.Included.Expressions,
+	} {
+		for kind, list := range exprs {
+			for _, expr := range list {
+				if _, err := compileExpression(expr); err != nil {
+					return fmt.Errorf("invalid %s expression for kind %s %q: %w", name, kind, expr, err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package types_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConfig_IsInstanceExcluded_expressions(t *testing.T) {
+	cm := func(name string, data map[string]any) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"kind": "ConfigMap",
+			"metadata": map[string]any{
+				"name":   name,
+				"labels": map[string]any{"app": name},
+			},
+			"data": data,
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		excluded map[string][]string
+		included map[string][]string
+		obj      unstructured.Unstructured
+		expected bool
+		rule     string
+	}{
+		{
+			name:     "should be excluded by a matching expression",
+			excluded: map[string][]string{"*": {`object.metadata.labels["app"].startsWith("tmp-")`}},
+			obj:      cm("tmp-1", map[string]any{"a": "b"}),
+			expected: true,
+			rule:     `expression object.metadata.labels["app"].startsWith("tmp-")`,
+		},
+		{
+			name:     "should not be excluded by a not matching expression",
+			excluded: map[string][]string{"group.kind": {`object.metadata.labels["app"].startsWith("tmp-")`}},
+			obj:      cm("app", map[string]any{"a": "b"}),
+			expected: false,
+		},
+		{
+			name:     "should be excluded if the data is empty",
+			excluded: map[string][]string{"group.kind": {`size(object.data) == 0`}},
+			obj:      cm("app", map[string]any{}),
+			expected: true,
+			rule:     "expression size(object.data) == 0",
+		},
+		{
+			name:     "should not match on evaluation errors",
+			excluded: map[string][]string{"group.kind": {`object.spec.replicas == 0`}},
+			obj:      cm("app", map[string]any{}),
+			expected: false,
+		},
+		{
+			name:     "should be included by a matching expression",
+			included: map[string][]string{"group.kind": {`has(object.data.a)`}},
+			obj:      cm("app", map[string]any{"a": "b"}),
+			expected: false,
+		},
+		{
+			name:     "should be excluded if no included expression matches",
+			included: map[string][]string{"group.kind": {`has(object.data.a)`}},
+			obj:      cm("app", map[string]any{"b": "c"}),
+			expected: true,
+			rule:     "not included by expression",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, res := setupConfig()
+			config.Excluded.Expressions = tt.excluded
+			config.Included.Expressions = tt.included
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := config.IsInstanceExcluded(res, tt.obj); got != tt.expected {
+				t.Errorf("IsInstanceExcluded() = %v, want %v", got, tt.expected)
+			}
+			if tt.rule != "" && res.ExcludedBy[tt.rule] != 1 {
+				t.Errorf("expected rule %q to be recorded, got %v", tt.rule, res.ExcludedBy)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_expressions(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "syntax error", expr: `object.metadata.name ==`},
+		{name: "not a bool", expr: `"foo"`},
+		{name: "unknown variable", expr: `obj.metadata.name == "foo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Excluded.Expressions = map[string][]string{"Pod": {tt.expr}}
+			if err := config.Validate(); err == nil {
+				t.Errorf("expected error for %q", tt.expr)
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const jsonPathRoot = "$"
+
+// parsed field paths are immutable and therefore shared.
+var fieldPaths sync.Map
+
+// fieldPath is a parsed field path. Paths are configured either
+//   - as list of keys e.g. [metadata, annotations, deployment.kubernetes.io/revision], where each key is literal
+//     unless it is a /regex/, list selectors are separate elements e.g. [spec, containers, '[?name=="app"]', image]
+//   - as single JSONPath-style expression starting with '$' e.g. [$.spec.containers[*].env[?name=="PASSWORD"].value],
+//     where keys may be globs and keys containing dots are quoted e.g. [$.data['tls.key']]
+//
+// Lists are traversed implicitly, if a key is applied to a list, it is applied to all items of the list.
+type fieldPath []*pathStep
+
+type pathStep struct {
+	key       *pattern
+	literal   bool
+	selectors []*listSelector
+}
+
+// listSelector selects items of a list by index ('[0]', '[-1]'), all items ('[*]')
+// or by a filter on a field of the items ('[?name=="PASSWORD"]', '[?name!="foo"]', '[?name=~"^kube-"]', '[?name]').
+type listSelector struct {
+	all   bool
+	index *int
+	field []string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+var filterOperators = []string{"==", "!=", "=~"}
+
+type parsedFieldPath struct {
+	path fieldPath
+	err  error
+}
+
+// fieldPathFor returns the parsed path, invalid paths are reported by Validate and never match.
+func fieldPathFor(fields ...string) fieldPath {
+	key := strings.Join(fields, "\x00")
+	if p, ok := fieldPaths.Load(key); ok {
+		//nolint:forcetypeassert
+		return p.(*parsedFieldPath).path
+	}
+	p := &parsedFieldPath{}
+	p.path, p.err = parseFieldPath(fields...)
+	if p.err != nil {
+		p.path = nil
+	}
+	fieldPaths.Store(key, p)
+	return p.path
+}
+
+func parseFieldPath(fields ...string) (fieldPath, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("empty field path")
+	}
+	if len(fields) == 1 && strings.HasPrefix(fields[0], jsonPathRoot) {
+		return parseJSONPath(fields[0])
+	}
+	var path fieldPath
+	for _, field := range fields {
+		switch {
+		case strings.HasPrefix(field, "["):
+			// selectors of the previous key e.g. [spec, containers, "[*]", image]
+			if len(path) == 0 {
+				return nil, fmt.Errorf("list selector %q without key", field)
+			}
+			sel, err := parseSelectors(field)
+			if err != nil {
+				return nil, err
+			}
+			path[len(path)-1].selectors = append(path[len(path)-1].selectors, sel...)
+		case isRegexPattern(field):
+			p, err := compilePattern(field)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, &pathStep{key: p})
+		default:
+			// keys of the array form are no globs, as map keys may contain '*', '?' and '['
+			path = append(path, &pathStep{key: &pattern{raw: field}, literal: true})
+		}
+	}
+	return path, nil
+}
+
+// parseStep parses a key with optional trailing list selectors.
+func parseStep(field string) (*pathStep, error) {
+	key := field
+	var selectors []*listSelector
+	if !isRegexPattern(field) {
+		// glob character classes e.g. 'tls.[ck]*' are no selectors, therefore the first suffix that parses is used
+		for i := range len(field) {
+			if field[i] != '[' || i == 0 {
+				continue
+			}
+			if sel, err := parseSelectors(field[i:]); err == nil {
+				key = field[:i]
+				selectors = sel
+				break
+			}
+		}
+	}
+	p, err := compilePattern(key)
+	if err != nil {
+		return nil, err
+	}
+	return &pathStep{key: p, selectors: selectors}, nil
+}
+
+// parseJSONPath parses a dotted path with a leading '$'.
+func parseJSONPath(s string) (fieldPath, error) {
+	expr := s
+	s = strings.TrimPrefix(s, jsonPathRoot)
+	if !strings.HasPrefix(s, ".") && !strings.HasPrefix(s, "[") {
+		s = "." + s
+	}
+	var path fieldPath
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in field path %q", expr)
+			}
+			step, err := parseStep(s[:end])
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, step)
+			s = s[end:]
+		case '[':
+			end, err := closingBracket(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", expr, err)
+			}
+			content := s[1:end]
+			s = s[end+1:]
+			if key, ok := unquote(content); ok {
+				// quoted key e.g. data['tls.key']
+				p, err := compilePattern(key)
+				if err != nil {
+					return nil, err
+				}
+				path = append(path, &pathStep{key: p})
+				continue
+			}
+			if len(path) == 0 {
+				return nil, fmt.Errorf("list selector without key in field path %q", expr)
+			}
+			sel, err := parseSelector(content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %q: %w", expr, err)
+			}
+			path[len(path)-1].selectors = append(path[len(path)-1].selectors, sel)
+		default:
+			return nil, fmt.Errorf("invalid field path %q", expr)
+		}
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty field path %q", expr)
+	}
+	return path, nil
+}
+
+// parseSelectors parses one or more selectors e.g. '[*]' or '[?name=="a"][0]'.
+func parseSelectors(s string) ([]*listSelector, error) {
+	var selectors []*listSelector
+	for s != "" {
+		if s[0] != '[' {
+			return nil, fmt.Errorf("invalid list selector %q", s)
+		}
+		end, err := closingBracket(s)
+		if err != nil {
+			return nil, err
+		}
+		sel, err := parseSelector(s[1:end])
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		s = s[end+1:]
+	}
+	return selectors, nil
+}
+
+func parseSelector(content string) (*listSelector, error) {
+	if content == "*" {
+		return &listSelector{all: true}, nil
+	}
+	if i, err := strconv.Atoi(content); err == nil {
+		return &listSelector{index: &i}, nil
+	}
+	if !strings.HasPrefix(content, "?") {
+		return nil, fmt.Errorf("invalid list selector [%s]", content)
+	}
+	expr := strings.TrimPrefix(content, "?")
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = expr[1 : len(expr)-1]
+	}
+	sel := &listSelector{}
+	field := expr
+	// the first operator wins, values may contain operators
+	opIndex := -1
+	for _, op := range filterOperators {
+		if i := strings.Index(expr, op); i > 0 && (opIndex < 0 || i < opIndex) {
+			opIndex, sel.op = i, op
+		}
+	}
+	if opIndex > 0 {
+		value, ok := unquote(strings.TrimSpace(expr[opIndex+len(sel.op):]))
+		if !ok {
+			value = strings.TrimSpace(expr[opIndex+len(sel.op):])
+		}
+		field, sel.value = expr[:opIndex], value
+	}
+	if sel.op == "=~" {
+		re, err := regexp.Compile(sel.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list filter [%s]: %w", content, err)
+		}
+		sel.re = re
+	}
+	field = strings.TrimPrefix(strings.TrimSpace(field), "@.")
+	if field == "" {
+		return nil, fmt.Errorf("invalid list filter [%s]", content)
+	}
+	sel.field = strings.Split(field, ".")
+	return sel, nil
+}
+
+// closingBracket returns the index of the bracket closing the one at index 0, brackets within quotes are ignored.
+func closingBracket(s string) (int, error) {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ']':
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unclosed bracket in %q", s)
+}
+
+func unquote(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// keys returns the keys of the map matching the step.
+func (s *pathStep) keys(m map[string]any) []string {
+	if !s.isPattern() {
+		if _, ok := m[s.key.raw]; ok {
+			return []string{s.key.raw}
+		}
+		return nil
+	}
+	var keys []string
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if s.key.match(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// isPattern returns true if the key of the step matches several keys.
+func (s *pathStep) isPattern() bool {
+	return !s.literal && isPattern(s.key.raw)
+}
+
+// items returns the indices of the list items matching all selectors of the step.
+func (s *pathStep) items(list []any) []int {
+	items := make([]int, len(list))
+	for i := range items {
+		items[i] = i
+	}
+	for _, sel := range s.selectors {
+		items = sel.apply(list, items)
+	}
+	return items
+}
+
+func (sel *listSelector) apply(list []any, items []int) []int {
+	switch {
+	case sel.all:
+		return items
+	case sel.index != nil:
+		i := *sel.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i >= len(items) {
+			return nil
+		}
+		return items[i : i+1]
+	}
+	var selected []int
+	for _, i := range items {
+		if sel.matches(list[i]) {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// matches evaluates the filter on the list item, items without the field never match.
+func (sel *listSelector) matches(item any) bool {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return false
+	}
+	v, found, err := unstructured.NestedFieldNoCopy(m, sel.field...)
+	if !found || err != nil {
+		return false
+	}
+	switch sel.op {
+	case "==":
+		return fmt.Sprint(v) == sel.value
+	case "!=":
+		return fmt.Sprint(v) != sel.value
+	case "=~":
+		return sel.re.MatchString(fmt.Sprint(v))
+	}
+	return true
+}
+
+// plain returns the keys if the path has no patterns and no list selectors.
+func (p fieldPath) plain() ([]string, bool) {
+	if len(p) == 0 {
+		return nil, false
+	}
+	keys := make([]string, 0, len(p))
+	for _, step := range p {
+		if len(step.selectors) > 0 || step.isPattern() {
+			return nil, false
+		}
+		keys = append(keys, step.key.raw)
+	}
+	return keys, true
+}
+
+// walk calls visit for each field matching the path. If the last step has list selectors,
+// visit is called with the list field and the indices of the selected items, otherwise items is nil.
+func (p fieldPath) walk(node any, visit func(m map[string]any, key string, items []int)) {
+	if len(p) == 0 {
+		return
+	}
+	switch n := node.(type) {
+	case []any:
+		for _, item := range n {
+			p.walk(item, visit)
+		}
+	case map[string]any:
+		step := p[0]
+		for _, key := range step.keys(n) {
+			if len(step.selectors) == 0 {
+				if len(p) == 1 {
+					visit(n, key, nil)
+				} else {
+					p[1:].walk(n[key], visit)
+				}
+				continue
+			}
+			list, ok := n[key].([]any)
+			if !ok {
+				continue
+			}
+			items := step.items(list)
+			if len(p) == 1 {
+				if len(items) > 0 {
+					visit(n, key, items)
+				}
+				continue
+			}
+			for _, i := range items {
+				p[1:].walk(list[i], visit)
+			}
+		}
+	}
+}
+
+func validateFieldPaths(name string, paths ...[]string) error {
+	for _, f := range paths {
+		if _, err := parseFieldPath(f...); err != nil {
+			return fmt.Errorf("invalid %s field %v: %w", name, f, err)
+		}
+	}
+	return nil
+}
+
+// validatePlainFieldPaths validates paths addressing a single field, patterns and list selectors are not supported.
+func validatePlainFieldPaths(name string, paths ...[]string) error {
+	for _, f := range paths {
+		p, err := parseFieldPath(f...)
+		if err != nil {
+			return fmt.Errorf("invalid %s field %v: %w", name, f, err)
+		}
+		if _, ok := p.plain(); !ok {
+			return fmt.Errorf("invalid %s field %v: patterns and list selectors are not supported", name, f)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateFieldPaths() error {
+	if err := validateFieldPaths("excluded", c.Excluded.Fields...); err != nil {
+		return err
+	}
+	if err := validatePlainFieldPaths("preserved", c.Excluded.PreservedFields.Fields...); err != nil {
+		return err
+	}
+	for kind, f := range c.Excluded.PreservedFields.KindFields {
+		if err := validatePlainFieldPaths("preserved "+kind, f...); err != nil {
+			return err
+		}
+	}
+	fields := map[string]KindFields{
+		"excluded": c.Excluded.KindFields,
+	}
+	if c.Masked != nil {
+		fields["masked"] = c.Masked.KindFields
+	}
+	if c.Encrypted != nil {
+		fields["encrypted"] = c.Encrypted.KindFields
+		fields["recipient encrypted"] = c.Encrypted.RecipientKindFields
+	}
+	for name, kf := range fields {
+		for kind, f := range kf {
+			if err := validateFieldPaths(name+" "+kind, f...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const fieldPathObject = `
+metadata:
+  annotations:
+    openshift.io/sa.scc.mcs: s0
+    openshift.io/sa.scc.uid-range: "1000"
+    app.openshift.io/vcs-ref: main
+    team: a
+data:
+  tls.key: key
+  tls.crt: crt
+  ca.crt: ca
+spec:
+  containers:
+    - name: app
+      env:
+        - name: USER
+          value: user
+        - name: PASSWORD
+          value: secret
+    - name: sidecar
+      env:
+        - name: PASSWORD
+          value: other
+  ports:
+    - port: 80
+    - port: 443
+`
+
+func TestRemoveNestedField(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		path     []string
+		expected any
+	}{
+		{
+			name:     "array form",
+			fields:   []string{"data", "tls.key"},
+			path:     []string{"data"},
+			expected: map[string]any{"tls.crt": "crt", "ca.crt": "ca"},
+		},
+		{
+			name:     "jsonpath with quoted key",
+			fields:   []string{"$.data['tls.key']"},
+			path:     []string{"data"},
+			expected: map[string]any{"tls.crt": "crt", "ca.crt": "ca"},
+		},
+		{
+			name:     "map key glob in jsonpath",
+			fields:   []string{"$.metadata.annotations['*openshift.io/*']"},
+			path:     []string{"metadata", "annotations"},
+			expected: map[string]any{"team": "a"},
+		},
+		{
+			name:     "quoted map key glob in jsonpath",
+			fields:   []string{"$.data['tls.*']"},
+			path:     []string{"data"},
+			expected: map[string]any{"ca.crt": "ca"},
+		},
+		{
+			name:     "map key regex",
+			fields:   []string{"metadata", "annotations", "/^openshift\\.io/"},
+			path:     []string{"metadata", "annotations"},
+			expected: map[string]any{"app.openshift.io/vcs-ref": "main", "team": "a"},
+		},
+		{
+			name:   "list filter",
+			fields: []string{`$.spec.containers[*].env[?name=="PASSWORD"]`},
+			path:   []string{"spec", "containers"},
+			expected: []any{
+				map[string]any{"name": "app", "env": []any{map[string]any{"name": "USER", "value": "user"}}},
+				map[string]any{"name": "sidecar", "env": []any{}},
+			},
+		},
+		{
+			name:   "list filter in array form",
+			fields: []string{"spec", "containers", "env", `[?name=="PASSWORD"]`, "value"},
+			path:   []string{"spec", "containers"},
+			expected: []any{
+				map[string]any{"name": "app", "env": []any{
+					map[string]any{"name": "USER", "value": "user"},
+					map[string]any{"name": "PASSWORD"},
+				}},
+				map[string]any{"name": "sidecar", "env": []any{map[string]any{"name": "PASSWORD"}}},
+			},
+		},
+		{
+			name:   "list filter by regex",
+			fields: []string{`$.spec.containers[?name=~"^side"]`},
+			path:   []string{"spec", "containers"},
+			expected: []any{map[string]any{"name": "app", "env": []any{
+				map[string]any{"name": "USER", "value": "user"},
+				map[string]any{"name": "PASSWORD", "value": "secret"},
+			}}},
+		},
+		{
+			name:     "list index",
+			fields:   []string{"$.spec.ports[-1]"},
+			path:     []string{"spec", "ports"},
+			expected: []any{map[string]any{"port": int64(80)}},
+		},
+		{
+			name:     "list filter on numbers",
+			fields:   []string{"spec", "ports", "[?port==80]"},
+			path:     []string{"spec", "ports"},
+			expected: []any{map[string]any{"port": int64(443)}},
+		},
+		{
+			name:   "implicit list traversal",
+			fields: []string{"spec", "containers", "env"},
+			path:   []string{"spec", "containers"},
+			expected: []any{
+				map[string]any{"name": "app"},
+				map[string]any{"name": "sidecar"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := map[string]any{}
+			if err := yaml.Unmarshal([]byte(fieldPathObject), &obj); err != nil {
+				t.Fatal(err)
+			}
+			removeNestedField(obj, tt.fields...)
+			got := nested(obj, tt.path...)
+			if !reflect.DeepEqual(normalize(got), normalize(tt.expected)) {
+				t.Errorf("removeNestedField() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRemoveNestedField_literalKeys(t *testing.T) {
+	obj := map[string]any{
+		"my.key": "a",
+		"my":     map[string]any{"key": "b"},
+		"data":   map[string]any{"tls.*": "c", "tls.key": "d", "list[0]": "e"},
+	}
+	removeNestedField(obj, "my.key")
+	removeNestedField(obj, "data", "tls.*")
+	removeNestedField(obj, "data", "list[0]")
+
+	expected := map[string]any{
+		"my":   map[string]any{"key": "b"},
+		"data": map[string]any{"tls.key": "d"},
+	}
+	if !reflect.DeepEqual(obj, expected) {
+		t.Errorf("removeNestedField() = %v, want %v", obj, expected)
+	}
+}
+
+func TestTransformNestedField(t *testing.T) {
+	obj := map[string]any{}
+	if err := yaml.Unmarshal([]byte(fieldPathObject), &obj); err != nil {
+		t.Fatal(err)
+	}
+	mask := func(any) any { return "***" }
+	transformNestedField(obj, mask, `$.spec.containers[?name=="app"].env[?name=="PASSWORD"].value`)
+	transformNestedField(obj, mask, "data", "tls.key")
+
+	if v := nested(obj, "data", "tls.key"); v != "***" {
+		t.Errorf("expected tls.key to be masked, got %v", v)
+	}
+	if v := nested(obj, "data", "ca.crt"); v != "ca" {
+		t.Errorf("expected ca.crt not to be masked, got %v", v)
+	}
+	containers, _ := nested(obj, "spec", "containers").([]any)
+	//nolint:forcetypeassert
+	app := containers[0].(map[string]any)["env"].([]any)
+	//nolint:forcetypeassert
+	sidecar := containers[1].(map[string]any)["env"].([]any)
+	if v := nested(app[1].(map[string]any), "value"); v != "***" {
+		t.Errorf("expected app PASSWORD to be masked, got %v", v)
+	}
+	if v := nested(app[0].(map[string]any), "value"); v != "user" {
+		t.Errorf("expected app USER not to be masked, got %v", v)
+	}
+	if v := nested(sidecar[0].(map[string]any), "value"); v != "other" {
+		t.Errorf("expected sidecar PASSWORD not to be masked, got %v", v)
+	}
+}
+
+func TestParseFieldPath(t *testing.T) {
+	for _, valid := range [][]string{
+		{"status"},
+		{"$.metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']"},
+		{"metadata", "annotations", "deployment.kubernetes.io/revision"},
+		{"spec", "containers", "[*]", "image"},
+		{"data", "tls.[ck]*"},
+		{"$.spec.containers[?(@.name == 'app')].image"},
+	} {
+		if _, err := parseFieldPath(valid...); err != nil {
+			t.Errorf("expected %v to be valid: %v", valid, err)
+		}
+	}
+	for _, invalid := range [][]string{
+		{},
+		{"$."},
+		{"$.spec.containers[?]"},
+		{`$.spec.containers[?name=~"("]`},
+		{"$.spec.containers[foo]"},
+		{"$.spec.containers[*"},
+		{"spec", "containers", "[*"},
+		{"[*]", "image"},
+		{"data", "/[/"},
+	} {
+		if _, err := parseFieldPath(invalid...); err == nil {
+			t.Errorf("expected %v to be invalid", invalid)
+		}
+	}
+}
+
+func nested(obj map[string]any, path ...string) any {
+	var v any = obj
+	for _, p := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+	return v
+}
+
+// normalize yaml numbers to int64.
+func normalize(v any) any {
+	switch e := v.(type) {
+	case map[string]any:
+		for k := range e {
+			e[k] = normalize(e[k])
+		}
+	case []any:
+		for i := range e {
+			e[i] = normalize(e[i])
+		}
+	case float64:
+		return int64(e)
+	}
+	return v
+}
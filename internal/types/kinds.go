@@ -0,0 +1,193 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// AllKinds matches all kinds.
+	AllKinds = "*"
+
+	kindRuleGroupPrefix    = "group:"
+	kindRuleCategoryPrefix = "category:"
+	coreGroup              = "core"
+)
+
+// Specificity of kind rules, if several rules match a kind, the most specific rule wins.
+const (
+	noMatch = iota - 1
+	specificityAll
+	specificityCategory
+	specificityGroup
+	specificityPattern
+	specificityExact
+)
+
+// compiled kind rules are immutable and therefore shared.
+var kindRules sync.Map
+
+// kindRule matches a group resource. Supported rules are:
+//   - the group kind e.g. 'apps.Deployment' or 'Pod'
+//   - a glob or /regex/ on the group kind e.g. '*kyverno.io.*'
+//   - an API group 'group:<glob or /regex/>' e.g. 'group:*.kyverno.io', 'group:core' for the core group
+//   - a discovery category 'category:<name>' e.g. 'category:all'
+//   - '*' for all kinds
+type kindRule struct {
+	specificity int
+	pattern     *pattern
+	category    string
+}
+
+func parseKindRule(rule string) (*kindRule, error) {
+	switch {
+	case rule == AllKinds:
+		return &kindRule{specificity: specificityAll}, nil
+	case strings.HasPrefix(rule, kindRuleCategoryPrefix):
+		return &kindRule{specificity: specificityCategory, category: strings.TrimPrefix(rule, kindRuleCategoryPrefix)}, nil
+	case strings.HasPrefix(rule, kindRuleGroupPrefix):
+		group := strings.TrimPrefix(rule, kindRuleGroupPrefix)
+		if group == coreGroup {
+			group = ""
+		}
+		p, err := compilePattern(group)
+		if err != nil {
+			return nil, err
+		}
+		return &kindRule{specificity: specificityGroup, pattern: p}, nil
+	case isPattern(rule):
+		p, err := compilePattern(rule)
+		if err != nil {
+			return nil, err
+		}
+		return &kindRule{specificity: specificityPattern, pattern: p}, nil
+	default:
+		return &kindRule{specificity: specificityExact, pattern: &pattern{raw: rule}}, nil
+	}
+}
+
+// kindRuleFor returns the compiled rule, invalid rules are reported by Validate and never match.
+func kindRuleFor(rule string) *kindRule {
+	if kr, ok := kindRules.Load(rule); ok {
+		//nolint:forcetypeassert
+		return kr.(*kindRule)
+	}
+	kr, err := parseKindRule(rule)
+	if err != nil {
+		kr = &kindRule{specificity: noMatch}
+	}
+	kindRules.Store(rule, kr)
+	return kr
+}
+
+func (r *kindRule) matches(gr *GroupResource) bool {
+	switch r.specificity {
+	case specificityAll:
+		return true
+	case specificityCategory:
+		return slices.Contains(gr.APIResource.Categories, r.category)
+	case specificityGroup:
+		return r.pattern.match(gr.APIGroup)
+	case specificityPattern:
+		return r.pattern.match(gr.GroupKind())
+	case specificityExact:
+		return r.pattern.raw == gr.GroupKind()
+	}
+	return false
+}
+
+// bestKindMatch returns the specificity of the most specific rule matching the group resource or noMatch.
+func bestKindMatch(rules []string, gr *GroupResource) int {
+	best := noMatch
+	for _, rule := range rules {
+		if kr := kindRuleFor(rule); kr.specificity > best && kr.matches(gr) {
+			best = kr.specificity
+		}
+	}
+	return best
+}
+
+// matchingKindKeys returns the keys of the kind map matching the group resource,
+// ordered from the least to the most specific rule.
+func matchingKindKeys[V any](m map[string]V, gr *GroupResource) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	if _, ok := m[gr.GroupKind()]; ok && len(m) == 1 {
+		return []string{gr.GroupKind()}
+	}
+	var keys []string
+	for key := range m {
+		if kindRuleFor(key).matches(gr) {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if d := kindRuleFor(a).specificity - kindRuleFor(b).specificity; d != 0 {
+			return d
+		}
+		return strings.Compare(a, b)
+	})
+	return keys
+}
+
+// ForResource returns the fields of all kind rules matching the group resource,
+// ordered from the least to the most specific rule.
+func (f KindFields) ForResource(gr *GroupResource) [][]string {
+	var fields [][]string
+	for _, key := range matchingKindKeys(f, gr) {
+		fields = append(fields, f[key]...)
+	}
+	return fields
+}
+
+func (c *Config) validateKindRules() error {
+	rules := map[string][]string{
+		"included":          c.Included.Kinds,
+		"excluded":          c.Excluded.Kinds,
+		"excluded field":    keysOf(c.Excluded.KindFields),
+		"preserved field":   keysOf(c.Excluded.PreservedFields.KindFields),
+		"excluded by field": keysOf(c.Excluded.KindsByField),
+		"excluded by expr":  keysOf(c.Excluded.Expressions),
+		"included by expr":  keysOf(c.Included.Expressions),
+		"excluded name":     keysOf(c.Excluded.Names),
+		"included name":     keysOf(c.Included.Names),
+		"sort slice":        keysOf(c.SortSlices),
+		"transform":         keysOf(c.Transform),
+		"strip defaults":    c.StripDefaults,
+		"kind selector":     keysOf(c.KindSelectors),
+		"exclude owned by":  c.OwnerReferences.ExcludeOwnedBy,
+	}
+	if c.Masked != nil {
+		rules["masked field"] = keysOf(c.Masked.KindFields)
+	}
+	if c.Encrypted != nil {
+		rules["encrypted field"] = keysOf(c.Encrypted.KindFields)
+		rules["recipient encrypted field"] = keysOf(c.Encrypted.RecipientKindFields)
+	}
+	for name, r := range rules {
+		if err := validateKindRules(name, r...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateKindRules(name string, rules ...string) error {
+	for _, rule := range rules {
+		if _, err := parseKindRule(rule); err != nil {
+			return fmt.Errorf("invalid %s kind rule %q: %w", name, rule, err)
+		}
+	}
+	return nil
+}
+
+func keysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,143 @@
+package types_test
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func groupResource(group, kind string, categories ...string) *types.GroupResource {
+	return &types.GroupResource{
+		APIGroup:    group,
+		APIResource: metav1.APIResource{Kind: kind, Categories: categories},
+	}
+}
+
+func TestConfig_IsExcluded_patterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      *types.GroupResource
+		included []string
+		excluded []string
+		expected bool
+	}{
+		{
+			name:     "should exclude by glob",
+			res:      groupResource("kyverno.io", "ClusterPolicy"),
+			excluded: []string{"*kyverno.io.*"},
+			expected: true,
+		},
+		{
+			name:     "should exclude by regex",
+			res:      groupResource("reports.kyverno.io", "ClusterEphemeralReport"),
+			excluded: []string{`/kyverno\.io\..*Report$/`},
+			expected: true,
+		},
+		{
+			name:     "should exclude the whole api group",
+			res:      groupResource("reports.kyverno.io", "EphemeralReport"),
+			excluded: []string{"group:*.kyverno.io"},
+			expected: true,
+		},
+		{
+			name:     "should exclude the core group",
+			res:      groupResource("", "ConfigMap"),
+			excluded: []string{"group:core"},
+			expected: true,
+		},
+		{
+			name:     "should not exclude other groups",
+			res:      groupResource("apps", "Deployment"),
+			excluded: []string{"group:*.kyverno.io", "group:core"},
+			expected: false,
+		},
+		{
+			name:     "should include by category",
+			res:      groupResource("apps", "Deployment", "all"),
+			included: []string{"category:all"},
+			expected: false,
+		},
+		{
+			name:     "should exclude kinds not in the category",
+			res:      groupResource("", "ConfigMap"),
+			included: []string{"category:all"},
+			expected: true,
+		},
+		{
+			name:     "should exclude all",
+			res:      groupResource("", "ConfigMap"),
+			excluded: []string{"*"},
+			expected: true,
+		},
+		{
+			name:     "more specific exclude wins over include",
+			res:      groupResource("apps", "ReplicaSet", "all"),
+			included: []string{"group:apps"},
+			excluded: []string{"apps.ReplicaSet"},
+			expected: true,
+		},
+		{
+			name:     "more specific include wins over exclude",
+			res:      groupResource("apps", "Deployment"),
+			included: []string{"apps.Deployment"},
+			excluded: []string{"group:apps"},
+			expected: false,
+		},
+		{
+			name:     "include wins on equal specificity",
+			res:      groupResource("apps", "Deployment"),
+			included: []string{"apps.Deployment"},
+			excluded: []string{"apps.Deployment"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Included.Kinds = tt.included
+			config.Excluded.Kinds = tt.excluded
+			if got := config.IsExcluded(tt.res); got != tt.expected {
+				t.Errorf("IsExcluded() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKindFields_ForResource(t *testing.T) {
+	kf := types.KindFields{
+		"*":               {{"metadata", "labels", "tmp"}},
+		"group:apps":      {{"spec", "replicas"}},
+		"apps.Deployment": {{"spec", "paused"}},
+		"Secret":          {{"data"}},
+	}
+
+	got := kf.ForResource(groupResource("apps", "Deployment"))
+	expected := [][]string{{"metadata", "labels", "tmp"}, {"spec", "replicas"}, {"spec", "paused"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ForResource() = %v, want %v", got, expected)
+	}
+
+	got = kf.ForResource(groupResource("", "ConfigMap"))
+	expected = [][]string{{"metadata", "labels", "tmp"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ForResource() = %v, want %v", got, expected)
+	}
+}
+
+func TestConfig_Validate_kindRules(t *testing.T) {
+	config, _ := setupConfig()
+	config.Excluded.Kinds = []string{"group:/kyverno(/"}
+	if err := config.Validate(); err == nil {
+		t.Error("expected invalid kind rule error")
+	}
+
+	config, _ = setupConfig()
+	config.Masked.KindFields = types.KindFields{"[": {{"data"}}}
+	if err := config.Validate(); err == nil {
+		t.Error("expected invalid kind rule error")
+	}
+}
@@ -1,23 +1,54 @@
 package types
 
 import (
+	"crypto/hmac"
 	"crypto/md5"  // #nosec G501 we are ok with md5
 	"crypto/sha1" // #nosec G505 we are ok with sha1
 	"crypto/sha256"
 	"fmt"
+	"os"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+const (
+	// EnvHmacKey env variable of the key for the hmac-sha256 checksum.
+	EnvHmacKey = "KUBEXPORTER_HMAC_KEY"
+
+	checksumHmacSHA256 = "hmac-sha256"
+)
+
 // Masked masking params.
 type Masked struct {
-	Replacement string     `docs:"The replacement value for masked fields"                                       json:"replacement" yaml:"replacement"`
-	Checksum    string     `docs:"The checksum algorithm to use for masked fields md5|sha1|sha256 (default md5)" json:"checksum"    yaml:"checksum"`
-	KindFields  KindFields `docs:"The fields to mask for each kind"                                              json:"kindFields"  yaml:"kindFields"`
-	doSum       func(string) string
+	Replacement     string            `docs:"The replacement value for masked fields"                                            json:"replacement"               yaml:"replacement"`
+	Checksum        string            `docs:"The checksum algorithm for masked fields md5|sha1|sha256|hmac-sha256 (default md5)" json:"checksum"                  yaml:"checksum"`
+	KindFields      KindFields        `docs:"The fields to mask for each kind"                                                   json:"kindFields"                yaml:"kindFields"`
+	Patterns        map[string]string `docs:"Named regexes masking matching string values of all kinds"                          json:"patterns,omitempty"        yaml:"patterns,omitempty"`
+	KeyPatterns     []string          `docs:"Key globs or /regexes/ masking string values of all kinds"                          json:"keyPatterns,omitempty"     yaml:"keyPatterns,omitempty"`
+	HmacKey         string            `docs:"The key of the hmac-sha256 checksum"                                                json:"hmacKey,omitempty"         yaml:"hmacKey,omitempty"`
+	HmacKeySecret   *SecretKeyRef     `docs:"The secret holding the key of the hmac-sha256 checksum"                             json:"hmacKeySecret,omitempty"   yaml:"hmacKeySecret,omitempty"`
+	DefaultPatterns bool              `docs:"Mask JWTs, AWS keys, private keys and password= values"                             json:"defaultPatterns,omitempty" yaml:"defaultPatterns,omitempty"`
+	doSum           func(string) string
+	patterns        []namedPattern
+	keyPatterns     []*pattern
+}
+
+// SecretKeyRef references a key of a secret.
+type SecretKeyRef struct {
+	Namespace string `docs:"The namespace of the secret" json:"namespace" yaml:"namespace"`
+	Name      string `docs:"The name of the secret"      json:"name"      yaml:"name"`
+	Key       string `docs:"The key within the secret"   json:"key"       yaml:"key"`
+}
+
+// UsesHmacKeySecret returns true if the hmac key has to be read from a secret.
+func (m *Masked) UsesHmacKeySecret() bool {
+	return m != nil && m.Checksum == checksumHmacSHA256 && m.HmacKeySecret != nil
 }
 
 func (m *Masked) Setup() error {
+	if k, ok := os.LookupEnv(EnvHmacKey); ok {
+		m.HmacKey = k
+	}
 	if m.Checksum != "" {
 		switch m.Checksum {
 		case "md5":
@@ -31,17 +62,29 @@ func (m *Masked) Setup() error {
 				return fmt.Sprintf("%x", sha1.Sum([]byte(s)))
 			}
 		case "sha256":
+			// sha224 is kept for compatibility with existing exports
 			m.doSum = func(s string) string {
 				return fmt.Sprintf("%x", sha256.Sum224([]byte(s)))
 			}
+		case checksumHmacSHA256:
+			if m.HmacKey == "" {
+				return fmt.Errorf("checksum %s needs a hmacKey, hmacKeySecret or the env variable %q",
+					checksumHmacSHA256, EnvHmacKey)
+			}
+			key := []byte(m.HmacKey)
+			m.doSum = func(s string) string {
+				mac := hmac.New(sha256.New, key)
+				mac.Write([]byte(s))
+				return fmt.Sprintf("%x", mac.Sum(nil))
+			}
 		default:
-			return fmt.Errorf("invalid checksum %q supported are: [md5/sha1/sha256]", m.Checksum)
+			return fmt.Errorf("invalid checksum %q supported are: [md5/sha1/sha256/%s]", m.Checksum, checksumHmacSHA256)
 		}
 	}
 	if m.Replacement == "" {
 		m.Replacement = DefaultMaskReplacement
 	}
-	return nil
+	return m.setupPatterns()
 }
 
 func (m *Masked) doMask(val any) string {
@@ -52,7 +95,18 @@ func (m *Masked) doMask(val any) string {
 	return m.Replacement
 }
 
+// mask masks string values, other types are kept, as their masked value would change the type of the field.
+// Nested maps and lists are walked by transformValue, so all string leaves of a masked field are masked.
+func (m *Masked) mask(val any) any {
+	switch val.(type) {
+	case string:
+		return m.doMask(val)
+	default:
+		return val
+	}
+}
+
 // MaskFields mask fields for a given resource.
 func (c *Config) MaskFields(res *GroupResource, us unstructured.Unstructured) {
-	transformNestedFields(c.Masked.KindFields, c.Masked.doMask, res.GroupKind(), us)
+	transformNestedFields(c.Masked.KindFields, c.Masked.mask, res, us)
 }
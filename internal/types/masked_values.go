@@ -0,0 +1,220 @@
+package types
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultMaskPatterns the built-in value patterns enabled with defaultPatterns.
+// If a pattern has a capture group, only the group is masked.
+var DefaultMaskPatterns = map[string]string{
+	"jwt":            `eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	"aws-access-key": `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	"private-key":    `(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`,
+	"password":       `(?i)\b(?:password|passwd|pwd)=([^;&\s"']+)`,
+}
+
+type namedPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// MaskedValue a value masked by a value or key pattern.
+type MaskedValue struct {
+	Namespace string
+	Name      string
+	Kind      string
+	Field     string
+	Pattern   string
+}
+
+// Report generates report rows.
+func (v MaskedValue) Report() []string {
+	return []string{v.Namespace, v.Kind, v.Name, v.Field, v.Pattern}
+}
+
+// HasPatterns returns true if string values are scanned by value or key patterns.
+func (m *Masked) HasPatterns() bool {
+	return m != nil && (len(m.patterns) > 0 || len(m.keyPatterns) > 0)
+}
+
+// PatternNames returns the names of the value patterns and the key patterns.
+func (m *Masked) PatternNames() []string {
+	var names []string
+	for _, p := range m.patterns {
+		names = append(names, p.name)
+	}
+	for _, p := range m.keyPatterns {
+		names = append(names, "key "+p.String())
+	}
+	return names
+}
+
+func (m *Masked) setupPatterns() error {
+	m.patterns = nil
+	all := make(map[string]string)
+	if m.DefaultPatterns {
+		maps.Copy(all, DefaultMaskPatterns)
+	}
+	for name, p := range m.Patterns {
+		// an empty pattern disables a default pattern
+		if p == "" {
+			delete(all, name)
+			continue
+		}
+		all[name] = p
+	}
+	for _, name := range slices.Sorted(maps.Keys(all)) {
+		re, err := regexp.Compile(all[name])
+		if err != nil {
+			return fmt.Errorf("invalid mask pattern %q: %w", name, err)
+		}
+		m.patterns = append(m.patterns, namedPattern{name: name, re: re})
+	}
+
+	m.keyPatterns = nil
+	for _, kp := range m.KeyPatterns {
+		// globs match keys case-insensitive
+		if !isRegexPattern(kp) {
+			kp = strings.ToLower(kp)
+		}
+		p, err := compilePattern(kp)
+		if err != nil {
+			return fmt.Errorf("invalid mask key pattern: %w", err)
+		}
+		m.keyPatterns = append(m.keyPatterns, p)
+	}
+	return nil
+}
+
+// MaskValues scans all string values of the resource and masks values matching a value pattern or having a key
+// matching a key pattern. Encrypted values are skipped. The masked values are recorded in the group resource.
+func (c *Config) MaskValues(res *GroupResource, us unstructured.Unstructured) {
+	if !c.Masked.HasPatterns() {
+		return
+	}
+	c.Masked.scan(us.Object, "", func(field, pattern string) {
+		res.MaskedValues = append(res.MaskedValues, MaskedValue{
+			Namespace: us.GetNamespace(),
+			Name:      us.GetName(),
+			Kind:      us.GetKind(),
+			Field:     field,
+			Pattern:   pattern,
+		})
+	})
+}
+
+func (m *Masked) scan(node any, path string, report func(field, pattern string)) {
+	switch v := node.(type) {
+	case map[string]any:
+		// sorted to report the masked values in a stable order
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			val := v[key]
+			field := joinField(path, key)
+			if s, ok := val.(string); ok && !isEncrypted(s) {
+				p := m.matchingKey(key)
+				if p == nil {
+					p = m.matchingName(v, key)
+				}
+				if p != nil {
+					v[key] = m.doMask(s)
+					report(field, "key "+p.String())
+					continue
+				}
+			}
+			v[key] = m.scanValue(val, field, report)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = m.scanValue(item, path+"["+strconv.Itoa(i)+"]", report)
+		}
+	}
+}
+
+func (m *Masked) scanValue(val any, field string, report func(field, pattern string)) any {
+	s, ok := val.(string)
+	if !ok {
+		m.scan(val, field, report)
+		return val
+	}
+	if isEncrypted(s) {
+		return s
+	}
+	for _, p := range m.patterns {
+		masked := m.maskMatches(p.re, s)
+		if masked != s {
+			report(field, p.name)
+			s = masked
+		}
+	}
+	return s
+}
+
+// maskMatches masks all matches of the regex, or only the first capture group if the regex has one.
+func (m *Masked) maskMatches(re *regexp.Regexp, s string) string {
+	var sb strings.Builder
+	last := 0
+	masked := false
+	for _, loc := range re.FindAllStringSubmatchIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if len(loc) >= 4 && loc[2] >= 0 {
+			start, end = loc[2], loc[3]
+		}
+		if start == end {
+			// empty matches are not masked
+			continue
+		}
+		sb.WriteString(s[last:start])
+		sb.WriteString(m.doMask(s[start:end]))
+		last = end
+		masked = true
+	}
+	if !masked {
+		return s
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+func (m *Masked) matchingKey(key string) *pattern {
+	for _, p := range m.keyPatterns {
+		k := key
+		if p.re == nil {
+			k = strings.ToLower(key)
+		}
+		if p.match(k) {
+			return p
+		}
+	}
+	return nil
+}
+
+// matchingName returns the key pattern matching the name of a name/value pair like an env variable,
+// if the key is the value of the pair.
+func (m *Masked) matchingName(obj map[string]any, key string) *pattern {
+	if key != "value" {
+		return nil
+	}
+	name, ok := obj["name"].(string)
+	if !ok {
+		return nil
+	}
+	return m.matchingKey(name)
+}
+
+// joinField appends the key to the field path, keys containing dots are quoted.
+func joinField(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return path + "['" + key + "']"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
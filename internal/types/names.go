@@ -0,0 +1,128 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const markerValue = "true"
+
+// compiled name patterns are immutable and therefore shared.
+var namePatterns sync.Map
+
+// namePattern matches the name of an instance, globs containing a '/' match 'namespace/name'.
+type namePattern struct {
+	*pattern
+	qualified bool
+}
+
+func compileNamePattern(p string) (*namePattern, error) {
+	cp, err := compilePattern(p)
+	if err != nil {
+		return nil, err
+	}
+	return &namePattern{pattern: cp, qualified: cp.re == nil && strings.Contains(p, "/")}, nil
+}
+
+// namePatternFor returns the compiled pattern, invalid patterns are reported by Validate and never match.
+func namePatternFor(p string) *namePattern {
+	if np, ok := namePatterns.Load(p); ok {
+		//nolint:forcetypeassert
+		return np.(*namePattern)
+	}
+	np, err := compileNamePattern(p)
+	if err != nil {
+		np = nil
+	}
+	namePatterns.Store(p, np)
+	return np
+}
+
+func (p *namePattern) matches(us unstructured.Unstructured) bool {
+	if p == nil {
+		return false
+	}
+	if p.qualified {
+		return p.match(us.GetNamespace() + "/" + us.GetName())
+	}
+	return p.match(us.GetName())
+}
+
+// markedBy returns the rule that excludes the instance, considering the opt-out and opt-in markers of the instance.
+// The opt-out marker wins over the opt-in marker, the opt-in marker only overrides the exclusion of the kind,
+// the instance rules still apply to opted in instances.
+func (c *Config) markedBy(res *GroupResource, us unstructured.Unstructured) string {
+	if hasMarker(us, c.Excluded.OptOutKey) {
+		return "opt-out " + c.Excluded.OptOutKey
+	}
+	if res.OptInOnly && !hasMarker(us, c.Included.OptInKey) {
+		return "not opted in"
+	}
+	return c.excludedBy(res, us)
+}
+
+// hasMarker returns true if the instance has a label or annotation with the key and the value 'true'.
+func hasMarker(us unstructured.Unstructured, key string) bool {
+	if key == "" {
+		return false
+	}
+	return us.GetLabels()[key] == markerValue || us.GetAnnotations()[key] == markerValue
+}
+
+// OptIn marks an excluded resource to export only opted in instances, which are selected by label server-side,
+// so excluded kinds are not listed in full. Returns false if no opt-in key is configured.
+func (c *Config) OptIn(res *GroupResource) bool {
+	if c.Included.OptInKey == "" {
+		return false
+	}
+	res.OptInOnly = true
+	res.Selector = res.Selector.and(Selector{LabelSelector: c.Included.OptInKey + "=" + markerValue})
+	return true
+}
+
+// excludedByName returns the name pattern that excludes the instance.
+// If included names match the kind, instances not matching any of them are excluded.
+func (c *Config) excludedByName(res *GroupResource, us unstructured.Unstructured) string {
+	for _, key := range matchingKindKeys(c.Excluded.Names, res) {
+		for _, p := range c.Excluded.Names[key] {
+			if namePatternFor(p).matches(us) {
+				return "name " + p
+			}
+		}
+	}
+
+	keys := matchingKindKeys(c.Included.Names, res)
+	if len(keys) == 0 {
+		return ""
+	}
+	for _, key := range keys {
+		for _, p := range c.Included.Names[key] {
+			if namePatternFor(p).matches(us) {
+				return ""
+			}
+		}
+	}
+	return "not included by name"
+}
+
+func (c *Config) validateNames() error {
+	for name, names := range map[string]map[string][]string{
+		"excluded": c.Excluded.Names,
+		"included": c.Included.Names,
+	} {
+		for kind, list := range names {
+			for _, p := range list {
+				if _, err := compileNamePattern(p); err != nil {
+					return fmt.Errorf("invalid %s name for kind %s: %w", name, kind, err)
+				}
+			}
+		}
+	}
+	if c.Included.OptInKey != "" && c.Included.OptInKey == c.Excluded.OptOutKey {
+		return fmt.Errorf("opt-in and opt-out key must differ: %q", c.Included.OptInKey)
+	}
+	return nil
+}
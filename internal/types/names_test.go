@@ -0,0 +1,196 @@
+package types_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func TestConfig_IsInstanceExcluded_names(t *testing.T) {
+	obj := func(namespace, name string, labels, annotations map[string]string) unstructured.Unstructured {
+		us := unstructured.Unstructured{}
+		us.SetKind("ConfigMap")
+		us.SetNamespace(namespace)
+		us.SetName(name)
+		us.SetLabels(labels)
+		us.SetAnnotations(annotations)
+		return us
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(c *types.Config, res *types.GroupResource)
+		obj      unstructured.Unstructured
+		expected bool
+		rule     string
+	}{
+		{
+			name: "should be excluded by a name glob",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Excluded.Names = map[string][]string{"*": {"kube-root-ca.*"}}
+			},
+			obj:      obj("ns", "kube-root-ca.crt", nil, nil),
+			expected: true,
+			rule:     "name kube-root-ca.*",
+		},
+		{
+			name: "should be excluded by a name regex",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Excluded.Names = map[string][]string{"group.kind": {"/^tmp-[0-9]+$/"}}
+			},
+			obj:      obj("ns", "tmp-42", nil, nil),
+			expected: true,
+			rule:     "name /^tmp-[0-9]+$/",
+		},
+		{
+			name: "should be excluded by a namespace/name glob",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Excluded.Names = map[string][]string{"group.kind": {"kube-*/cfg"}}
+			},
+			obj:      obj("kube-system", "cfg", nil, nil),
+			expected: true,
+			rule:     "name kube-*/cfg",
+		},
+		{
+			name: "should not be excluded by a namespace/name glob of another namespace",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Excluded.Names = map[string][]string{"group.kind": {"kube-*/cfg"}}
+			},
+			obj:      obj("ns", "cfg", nil, nil),
+			expected: false,
+		},
+		{
+			name: "should be excluded if no included name matches",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Included.Names = map[string][]string{"group.kind": {"app-*"}}
+			},
+			obj:      obj("ns", "cfg", nil, nil),
+			expected: true,
+			rule:     "not included by name",
+		},
+		{
+			name: "should not be excluded if an included name matches",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Included.Names = map[string][]string{"group.kind": {"app-*"}}
+			},
+			obj:      obj("ns", "app-1", nil, nil),
+			expected: false,
+		},
+		{
+			name:     "should be excluded by the default opt-out annotation",
+			setup:    func(_ *types.Config, _ *types.GroupResource) {},
+			obj:      obj("ns", "cfg", nil, map[string]string{types.DefaultOptOutKey: "true"}),
+			expected: true,
+			rule:     "opt-out " + types.DefaultOptOutKey,
+		},
+		{
+			name: "should be excluded by a custom opt-out label",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Excluded.OptOutKey = "example.com/skip"
+			},
+			obj:      obj("ns", "cfg", map[string]string{"example.com/skip": "true"}, nil),
+			expected: true,
+			rule:     "opt-out example.com/skip",
+		},
+		{
+			name:     "should not be excluded if the opt-out value is not true",
+			setup:    func(_ *types.Config, _ *types.GroupResource) {},
+			obj:      obj("ns", "cfg", nil, map[string]string{types.DefaultOptOutKey: "false"}),
+			expected: false,
+		},
+		{
+			name: "should be excluded by a name pattern if opted in",
+			setup: func(c *types.Config, res *types.GroupResource) {
+				c.Included.OptInKey = "example.com/export"
+				c.Excluded.Kinds = []string{"group.kind"}
+				c.Excluded.Names = map[string][]string{"group.kind": {"cf*"}}
+				c.OptIn(res)
+			},
+			obj:      obj("ns", "cfg", map[string]string{"example.com/export": "true"}, nil),
+			expected: true,
+			rule:     "name cf*",
+		},
+		{
+			name: "should be excluded if opted in and opted out",
+			setup: func(c *types.Config, _ *types.GroupResource) {
+				c.Included.OptInKey = "example.com/export"
+			},
+			obj: obj("ns", "cfg", nil, map[string]string{
+				"example.com/export":   "true",
+				types.DefaultOptOutKey: "true",
+			}),
+			expected: true,
+			rule:     "opt-out " + types.DefaultOptOutKey,
+		},
+		{
+			name: "should be excluded if the kind is excluded and not opted in",
+			setup: func(c *types.Config, res *types.GroupResource) {
+				c.Included.OptInKey = "example.com/export"
+				c.Excluded.Kinds = []string{"group.kind"}
+				c.OptIn(res)
+			},
+			obj:      obj("ns", "cfg", nil, nil),
+			expected: true,
+			rule:     "not opted in",
+		},
+		{
+			name: "should not be excluded if the kind is excluded and opted in",
+			setup: func(c *types.Config, res *types.GroupResource) {
+				c.Included.OptInKey = "example.com/export"
+				c.Excluded.Kinds = []string{"group.kind"}
+				c.OptIn(res)
+			},
+			obj:      obj("ns", "cfg", map[string]string{"example.com/export": "true"}, nil),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, res := setupConfig()
+			tt.setup(config, res)
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if got := config.IsInstanceExcluded(res, tt.obj); got != tt.expected {
+				t.Errorf("IsInstanceExcluded() = %v, want %v", got, tt.expected)
+			}
+			if tt.rule != "" && res.ExcludedBy[tt.rule] != 1 {
+				t.Errorf("expected rule %q to be recorded, got %v", tt.rule, res.ExcludedBy)
+			}
+		})
+	}
+}
+
+func TestConfig_OptIn(t *testing.T) {
+	config, res := setupConfig()
+	config.Selector.LabelSelector = "app=foo"
+	if config.OptIn(res) {
+		t.Fatal("expected no opt-in without key")
+	}
+	config.Included.OptInKey = "example.com/export"
+	config.Selector.LabelSelector = "app=foo"
+	res.Selector = config.SelectorFor(res)
+	if !config.OptIn(res) || !res.OptInOnly {
+		t.Fatal("expected opt-in")
+	}
+	if res.Selector.LabelSelector != "app=foo,example.com/export=true" {
+		t.Errorf("unexpected selector %q", res.Selector.LabelSelector)
+	}
+}
+
+func TestConfig_Validate_names(t *testing.T) {
+	config, _ := setupConfig()
+	config.Excluded.Names = map[string][]string{"Pod": {"/[/"}}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+
+	config, _ = setupConfig()
+	config.Included.OptInKey = types.DefaultOptOutKey
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for equal opt-in and opt-out key")
+	}
+}
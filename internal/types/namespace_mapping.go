@@ -0,0 +1,255 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// serviceDNSName matches service dns names like 'svc.ns.svc' or 'svc.ns.svc.cluster.local'.
+var serviceDNSName = regexp.MustCompile(`\b([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.svc\b`)
+
+// NamespaceMapping maps source namespaces to target namespaces.
+type NamespaceMapping map[string]string
+
+// NamespaceReference a namespace reference of a resource.
+type NamespaceReference struct {
+	Namespace string
+	Name      string
+	Kind      string
+	Field     string
+	From      string
+	To        string
+	// Reason is set if the reference could not be mapped.
+	Reason string
+}
+
+// Report generates report rows.
+func (r NamespaceReference) Report() []string {
+	return []string{r.Namespace, r.Kind, r.Name, r.Field, r.From, r.Reason}
+}
+
+func (m NamespaceMapping) String() string {
+	var mappings []string
+	for k, v := range m {
+		mappings = append(mappings, k+" → "+v)
+	}
+	slices.Sort(mappings)
+	return strings.Join(mappings, ", ")
+}
+
+func (m NamespaceMapping) target(ns string) (string, bool) {
+	if ns == "" {
+		return ns, false
+	}
+	t, ok := m[ns]
+	return t, ok && t != ns
+}
+
+// Apply maps the namespace of the resource and well-known namespace references.
+// Mapped and unmapped references are returned, unmapped references are references to mapped namespaces
+// that can not be mapped automatically.
+func (m NamespaceMapping) Apply(us *unstructured.Unstructured) (mapped, unmapped []NamespaceReference) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	ns := us.GetNamespace()
+	ref := func(field, from, to, reason string) NamespaceReference {
+		return NamespaceReference{
+			Namespace: ns,
+			Name:      us.GetName(),
+			Kind:      us.GetKind(),
+			Field:     field,
+			From:      from,
+			To:        to,
+			Reason:    reason,
+		}
+	}
+	mapValue := func(field string, obj map[string]any, key string) {
+		if v, ok := obj[key].(string); ok {
+			if t, ok := m.target(v); ok {
+				obj[key] = t
+				mapped = append(mapped, ref(field, v, t, ""))
+			}
+		}
+	}
+
+	if md, ok := us.Object["metadata"].(map[string]any); ok {
+		mapValue("metadata.namespace", md, "namespace")
+	}
+
+	gk := us.GroupVersionKind().GroupKind()
+	switch gk.String() {
+	case "RoleBinding.rbac.authorization.k8s.io", "ClusterRoleBinding.rbac.authorization.k8s.io":
+		if subjects, ok := us.Object["subjects"].([]any); ok {
+			for i, s := range subjects {
+				if sm, ok := s.(map[string]any); ok {
+					mapValue(fmt.Sprintf("subjects[%d].namespace", i), sm, "namespace")
+				}
+			}
+		}
+	case "PersistentVolume":
+		if claimRef, ok, _ := unstructured.NestedMap(us.Object, "spec", "claimRef"); ok {
+			mapValue("spec.claimRef.namespace", claimRef, "namespace")
+			_ = unstructured.SetNestedMap(us.Object, claimRef, "spec", "claimRef")
+		}
+	}
+
+	rewriteDNS := gk.Kind == "Ingress" || gk.Kind == "Service"
+	walkNamespaceRefs(us.Object, "", func(field string, obj map[string]any, key string) {
+		switch key {
+		case "namespaceSelector":
+			sel, ok := obj[key].(map[string]any)
+			if !ok {
+				return
+			}
+			mapped, unmapped = m.mapSelector(field, sel, ref, mapped, unmapped)
+		case "namespaces":
+			// namespaces of pod affinity terms
+			if _, ok := obj["topologyKey"]; !ok {
+				return
+			}
+			if nss, ok := obj[key].([]any); ok {
+				for i, n := range nss {
+					if s, ok := n.(string); ok {
+						if t, ok := m.target(s); ok {
+							nss[i] = t
+							mapped = append(mapped, ref(fmt.Sprintf("%s[%d]", field, i), s, t, ""))
+						}
+					}
+				}
+			}
+		default:
+			s, ok := obj[key].(string)
+			if !ok || !strings.Contains(s, ".svc") {
+				return
+			}
+			for _, match := range serviceDNSName.FindAllStringSubmatch(s, -1) {
+				if _, ok := m.target(match[3]); !ok {
+					continue
+				}
+				if !rewriteDNS {
+					unmapped = append(unmapped, ref(field, s, "", "service dns name in "+gk.Kind))
+					return
+				}
+			}
+			if rewriteDNS {
+				r := serviceDNSName.ReplaceAllStringFunc(s, func(dns string) string {
+					parts := serviceDNSName.FindStringSubmatch(dns)
+					if t, ok := m.target(parts[3]); ok {
+						return parts[1] + "." + t + ".svc"
+					}
+					return dns
+				})
+				if r != s {
+					obj[key] = r
+					mapped = append(mapped, ref(field, s, r, ""))
+				}
+			}
+		}
+	})
+	return mapped, unmapped
+}
+
+func (m NamespaceMapping) mapSelector(
+	field string,
+	sel map[string]any,
+	ref func(field, from, to, reason string) NamespaceReference,
+	mapped, unmapped []NamespaceReference,
+) (mappedOut, unmappedOut []NamespaceReference) {
+	byName := false
+	if labels, ok := sel["matchLabels"].(map[string]any); ok {
+		if v, ok := labels[namespaceNameLabel].(string); ok {
+			byName = true
+			if t, ok := m.target(v); ok {
+				labels[namespaceNameLabel] = t
+				mapped = append(mapped, ref(field+".matchLabels", v, t, ""))
+			}
+		}
+	}
+	if exprs, ok := sel["matchExpressions"].([]any); ok {
+		for i, e := range exprs {
+			expr, ok := e.(map[string]any)
+			if !ok || expr["key"] != namespaceNameLabel {
+				continue
+			}
+			byName = true
+			values, _ := expr["values"].([]any)
+			for j, v := range values {
+				if s, ok := v.(string); ok {
+					if t, ok := m.target(s); ok {
+						values[j] = t
+						mapped = append(mapped, ref(fmt.Sprintf("%s.matchExpressions[%d].values[%d]", field, i, j), s, t, ""))
+					}
+				}
+			}
+		}
+	}
+	if !byName && len(sel) > 0 {
+		unmapped = append(unmapped, ref(field, selectorString(sel), "", "namespace selector without namespace name"))
+	}
+	return mapped, unmapped
+}
+
+// walkNamespaceRefs calls fn with the field path for each value of all nested maps, slices are traversed.
+// Namespace selectors and namespace lists are passed as a whole.
+func walkNamespaceRefs(obj map[string]any, path string, fn func(field string, obj map[string]any, key string)) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		if path == "" && k == "metadata" {
+			// only annotations are relevant in metadata
+			if a, ok, _ := unstructured.NestedMap(obj, "metadata", "annotations"); ok {
+				walkNamespaceRefs(a, "metadata.annotations", fn)
+				_ = unstructured.SetNestedMap(obj, a, "metadata", "annotations")
+			}
+			continue
+		}
+		field := k
+		if path != "" {
+			field = path + "." + k
+		}
+		switch v := obj[k].(type) {
+		case map[string]any:
+			if k == "namespaceSelector" {
+				fn(field, obj, k)
+				continue
+			}
+			walkNamespaceRefs(v, field, fn)
+		case []any:
+			if k == "namespaces" {
+				fn(field, obj, k)
+				continue
+			}
+			for i, item := range v {
+				if m, ok := item.(map[string]any); ok {
+					walkNamespaceRefs(m, fmt.Sprintf("%s[%d]", field, i), fn)
+				}
+			}
+		default:
+			fn(field, obj, k)
+		}
+	}
+}
+
+func selectorString(sel map[string]any) string {
+	var parts []string
+	if labels, ok := sel["matchLabels"].(map[string]any); ok {
+		for k, v := range labels {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	if exprs, ok := sel["matchExpressions"].([]any); ok {
+		parts = append(parts, fmt.Sprintf("%d expression(s)", len(exprs)))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,147 @@
+package types_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func TestNamespaceMapping_Apply(t *testing.T) {
+	mapping := types.NamespaceMapping{"team-a-prod": "team-a-staging"}
+
+	tests := []struct {
+		name     string
+		obj      map[string]any
+		expected map[string]any
+		mapped   int
+		unmapped int
+	}{
+		{
+			name: "should map the namespace",
+			obj: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "cm", "namespace": "team-a-prod"},
+			},
+			expected: map[string]any{"metadata.namespace": "team-a-staging"},
+			mapped:   1,
+		},
+		{
+			name: "should not map other namespaces",
+			obj: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "cm", "namespace": "other"},
+			},
+			expected: map[string]any{"metadata.namespace": "other"},
+		},
+		{
+			name: "should map role binding subjects",
+			obj: map[string]any{
+				"apiVersion": "rbac.authorization.k8s.io/v1",
+				"kind":       "ClusterRoleBinding",
+				"metadata":   map[string]any{"name": "crb"},
+				"subjects": []any{
+					map[string]any{"kind": "ServiceAccount", "name": "sa", "namespace": "team-a-prod"},
+					map[string]any{"kind": "ServiceAccount", "name": "sa", "namespace": "other"},
+				},
+			},
+			mapped: 1,
+		},
+		{
+			name: "should map service dns names in ingress backends",
+			obj: map[string]any{
+				"apiVersion": "networking.k8s.io/v1",
+				"kind":       "Ingress",
+				"metadata": map[string]any{
+					"name":      "ing",
+					"namespace": "other",
+					"annotations": map[string]any{
+						"nginx.ingress.kubernetes.io/upstream-vhost": "api.team-a-prod.svc.cluster.local",
+					},
+				},
+			},
+			mapped: 1,
+		},
+		{
+			name: "should report service dns names in other kinds",
+			obj: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "cm", "namespace": "other"},
+				"data":       map[string]any{"url": "http://api.team-a-prod.svc:8080"},
+			},
+			unmapped: 1,
+		},
+		{
+			name: "should map namespace selectors by name and report others",
+			obj: map[string]any{
+				"apiVersion": "networking.k8s.io/v1",
+				"kind":       "NetworkPolicy",
+				"metadata":   map[string]any{"name": "np", "namespace": "team-a-prod"},
+				"spec": map[string]any{
+					"ingress": []any{
+						map[string]any{"from": []any{
+							map[string]any{"namespaceSelector": map[string]any{
+								"matchLabels": map[string]any{"kubernetes.io/metadata.name": "team-a-prod"},
+							}},
+							map[string]any{"namespaceSelector": map[string]any{
+								"matchLabels": map[string]any{"team": "a"},
+							}},
+						}},
+					},
+				},
+			},
+			mapped:   2,
+			unmapped: 1,
+		},
+		{
+			name: "should map pod affinity namespaces",
+			obj: map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "d", "namespace": "other"},
+				"spec": map[string]any{
+					"podAffinityTerm": map[string]any{
+						"topologyKey": "kubernetes.io/hostname",
+						"namespaces":  []any{"team-a-prod", "other"},
+					},
+				},
+			},
+			mapped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &unstructured.Unstructured{Object: tt.obj}
+			mapped, unmapped := mapping.Apply(us)
+			if len(mapped) != tt.mapped {
+				t.Errorf("Apply() mapped = %v, want %d", mapped, tt.mapped)
+			}
+			if len(unmapped) != tt.unmapped {
+				t.Errorf("Apply() unmapped = %v, want %d", unmapped, tt.unmapped)
+			}
+			for _, m := range mapped {
+				if m.To != "team-a-staging" && m.To != "api.team-a-staging.svc.cluster.local" {
+					t.Errorf("unexpected mapping %v", m)
+				}
+			}
+			if ns, ok := tt.expected["metadata.namespace"]; ok && us.GetNamespace() != ns {
+				t.Errorf("namespace = %q, want %q", us.GetNamespace(), ns)
+			}
+		})
+	}
+}
+
+func TestNamespaceMapping_Empty(t *testing.T) {
+	us := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "cm", "namespace": "team-a-prod"},
+	}}
+	mapped, unmapped := types.NamespaceMapping(nil).Apply(us)
+	if mapped != nil || unmapped != nil {
+		t.Errorf("expected no references, got %v %v", mapped, unmapped)
+	}
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceLister lists the names of the namespaces matching the label selector.
+type NamespaceLister func(labelSelector string) ([]string, error)
+
+// NeedsNamespaceResolution returns true if the namespaces have to be resolved against the cluster,
+// because a namespace selector, excluded namespaces or namespace patterns are defined.
+func (c *Config) NeedsNamespaceResolution() bool {
+	if c.NamespaceSelector != "" || len(c.Excluded.Namespaces) > 0 {
+		return true
+	}
+	return slices.ContainsFunc(c.Namespaces, isPattern)
+}
+
+// ResolveNamespaces resolves the namespaces to the namespaces of the cluster. The namespaces matching the selector and
+// any of the namespace names or patterns are selected first, then the excluded namespaces are subtracted.
+func (c *Config) ResolveNamespaces(list NamespaceLister) error {
+	if !c.NeedsNamespaceResolution() {
+		return nil
+	}
+
+	included, err := compilePatterns(c.selectedNamespaces()...)
+	if err != nil {
+		return err
+	}
+	excluded, err := compilePatterns(c.Excluded.Namespaces...)
+	if err != nil {
+		return err
+	}
+
+	names, err := list(c.NamespaceSelector)
+	if err != nil {
+		return fmt.Errorf("error listing namespaces: %w", err)
+	}
+
+	var resolved []string
+	for _, name := range names {
+		if (len(included) == 0 || matchesAny(included, name)) && !matchesAny(excluded, name) {
+			resolved = append(resolved, name)
+		}
+	}
+	if len(resolved) == 0 {
+		return errors.New("no namespace matches the namespace selection")
+	}
+	slices.Sort(resolved)
+	c.Namespaces = slices.Compact(resolved)
+	return nil
+}
+
+func (c *Config) selectedNamespaces() []string {
+	return slices.DeleteFunc(slices.Clone(c.Namespaces), func(ns string) bool { return ns == "" })
+}
+
+func (c *Config) validateNamespaces() error {
+	if _, err := labels.Parse(c.NamespaceSelector); err != nil {
+		return fmt.Errorf("invalid namespace selector %q: %w", c.NamespaceSelector, err)
+	}
+	if _, err := compilePatterns(c.selectedNamespaces()...); err != nil {
+		return fmt.Errorf("invalid namespace: %w", err)
+	}
+	if _, err := compilePatterns(c.Excluded.Namespaces...); err != nil {
+		return fmt.Errorf("invalid excluded namespace: %w", err)
+	}
+	return nil
+}
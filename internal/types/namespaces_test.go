@@ -0,0 +1,138 @@
+package types_test
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfig_ResolveNamespaces(t *testing.T) {
+	cluster := map[string][]string{
+		"":           {"default", "kube-public", "kube-system", "openshift-dns", "team-a", "team-b", "team-c"},
+		"tenant=foo": {"team-a", "team-b", "kube-system"},
+	}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		selector   string
+		excluded   []string
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "should not resolve plain namespaces",
+			namespaces: []string{"team-a", "unknown"},
+			expected:   []string{"team-a", "unknown"},
+		},
+		{
+			name:       "should resolve globs",
+			namespaces: []string{"team-*"},
+			expected:   []string{"team-a", "team-b", "team-c"},
+		},
+		{
+			name:       "should resolve regexes and plain names",
+			namespaces: []string{"/^team-(a|c)$/", "default"},
+			expected:   []string{"default", "team-a", "team-c"},
+		},
+		{
+			name:     "should resolve the label selector",
+			selector: "tenant=foo",
+			excluded: []string{"kube-*"},
+			expected: []string{"team-a", "team-b"},
+		},
+		{
+			name:     "should exclude namespaces from all",
+			excluded: []string{"kube-*", "/^openshift-/"},
+			expected: []string{"default", "team-a", "team-b", "team-c"},
+		},
+		{
+			name:       "should fail if nothing matches",
+			namespaces: []string{"other-*"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Namespaces = tt.namespaces
+			config.NamespaceSelector = tt.selector
+			config.Excluded.Namespaces = tt.excluded
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := config.ResolveNamespaces(func(labelSelector string) ([]string, error) {
+				return cluster[labelSelector], nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveNamespaces() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(config.Namespaces, tt.expected) {
+				t.Errorf("ResolveNamespaces() = %v, want %v", config.Namespaces, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfig_HasNamespaces(t *testing.T) {
+	config, _ := setupConfig()
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if config.HasNamespaces() {
+		t.Errorf("expected no namespaces, got %v", config.Namespaces)
+	}
+	config.Namespaces = []string{"ns1"}
+	if !config.HasNamespaces() {
+		t.Error("expected namespaces")
+	}
+}
+
+func TestConfig_SelectsNamespaces(t *testing.T) {
+	list := func(string) ([]string, error) { return []string{"default", "kube-system", "team-a"}, nil }
+	tests := []struct {
+		name       string
+		namespaces []string
+		selector   string
+		excluded   []string
+		expected   bool
+	}{
+		{name: "all namespaces"},
+		{name: "excluded namespaces only", excluded: []string{"kube-*"}},
+		{name: "namespace selector only", selector: "tenant=foo"},
+		{name: "selected namespace", namespaces: []string{"team-a"}, expected: true},
+		{name: "selected pattern", namespaces: []string{"team-*"}, excluded: []string{"kube-*"}, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Namespaces = tt.namespaces
+			config.NamespaceSelector = tt.selector
+			config.Excluded.Namespaces = tt.excluded
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			if err := config.ResolveNamespaces(list); err != nil {
+				t.Fatal(err)
+			}
+			if got := config.SelectsNamespaces(); got != tt.expected {
+				t.Errorf("SelectsNamespaces() = %v, want %v (namespaces %v)", got, tt.expected, config.Namespaces)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_namespaces(t *testing.T) {
+	config, _ := setupConfig()
+	config.Excluded.Namespaces = []string{"/team-(/"}
+	if err := config.Validate(); err == nil {
+		t.Error("expected invalid regex error")
+	}
+
+	config, _ = setupConfig()
+	config.NamespaceSelector = "tenant in foo"
+	if err := config.Validate(); err == nil {
+		t.Error("expected invalid selector error")
+	}
+}
@@ -0,0 +1,126 @@
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultTerminationMessagePath   = "/dev/termination-log"
+	defaultTerminationMessagePolicy = "File"
+	headlessClusterIP               = "None"
+	serviceAccountTokenVolumes      = `^kube-api-access-[a-z0-9]{5}$`
+)
+
+// neatRule removes a server-populated field. If ifValue is set, the field is only removed if it has this value,
+// if unlessValue is set, the field is kept if it has this value.
+type neatRule struct {
+	path        []string
+	ifValue     string
+	unlessValue string
+}
+
+// neatRules curated rules per kind rule to strip fields populated by the API server or controllers.
+var neatRules = func() map[string][]neatRule {
+	rules := map[string][]neatRule{
+		AllKinds: {
+			{path: []string{"metadata", "managedFields"}},
+			{path: []string{"metadata", "finalizers"}},
+		},
+		"Namespace": {
+			{path: []string{"spec", "finalizers"}},
+		},
+		"Service": {
+			{path: []string{"spec", "clusterIP"}, unlessValue: headlessClusterIP},
+			{path: []string{"spec", "clusterIPs"}},
+		},
+		"PersistentVolume": {
+			{path: []string{"spec", "claimRef"}},
+		},
+		"PersistentVolumeClaim": {
+			{path: []string{"metadata", "annotations", `/^pv\.kubernetes\.io//`}},
+			{path: []string{"metadata", "annotations", `/^volume\.(beta\.)?kubernetes\.io/storage-provisioner$/`}},
+		},
+		"apps.Deployment": {
+			{path: []string{"metadata", "annotations", "deployment.kubernetes.io/revision"}},
+		},
+		"admissionregistration.k8s.io.MutatingWebhookConfiguration": {
+			{path: []string{"webhooks", "clientConfig", "caBundle"}},
+		},
+		"admissionregistration.k8s.io.ValidatingWebhookConfiguration": {
+			{path: []string{"webhooks", "clientConfig", "caBundle"}},
+		},
+		"apiextensions.k8s.io.CustomResourceDefinition": {
+			{path: []string{"spec", "conversion", "webhook", "clientConfig", "caBundle"}},
+		},
+		"apiregistration.k8s.io.APIService": {
+			{path: []string{"spec", "caBundle"}},
+		},
+	}
+
+	podSpecs := map[string][]string{
+		"Pod":                   {"spec"},
+		"ReplicationController": {"spec", "template", "spec"},
+		"PodTemplate":           {"template", "spec"},
+		"apps.Deployment":       {"spec", "template", "spec"},
+		"apps.ReplicaSet":       {"spec", "template", "spec"},
+		"apps.StatefulSet":      {"spec", "template", "spec"},
+		"apps.DaemonSet":        {"spec", "template", "spec"},
+		"batch.Job":             {"spec", "template", "spec"},
+		"batch.CronJob":         {"spec", "jobTemplate", "spec", "template", "spec"},
+	}
+	for kind, spec := range podSpecs {
+		rules[kind] = append(rules[kind], podSpecRules(spec...)...)
+	}
+	return rules
+}()
+
+// podSpecRules strip the scheduling result, the injected service account token and container defaults.
+func podSpecRules(spec ...string) []neatRule {
+	at := func(fields ...string) []string {
+		return append(append([]string{}, spec...), fields...)
+	}
+	rules := []neatRule{
+		{path: at("nodeName")},
+		{path: at("serviceAccount")},
+		{path: at("volumes", `[?name=~"`+serviceAccountTokenVolumes+`"]`)},
+	}
+	for _, containers := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		rules = append(rules,
+			neatRule{path: at(containers, "volumeMounts", `[?name=~"`+serviceAccountTokenVolumes+`"]`)},
+			neatRule{path: at(containers, "terminationMessagePath"), ifValue: defaultTerminationMessagePath},
+			neatRule{path: at(containers, "terminationMessagePolicy"), ifValue: defaultTerminationMessagePolicy},
+		)
+	}
+	return rules
+}
+
+// NeatFields strips server-populated fields and defaults, so the exported manifests can be re-applied.
+func (c *Config) NeatFields(res *GroupResource, us unstructured.Unstructured) {
+	if !c.Neat {
+		return
+	}
+	for _, key := range matchingKindKeys(neatRules, res) {
+		for _, rule := range neatRules[key] {
+			rule.apply(us.Object)
+		}
+	}
+}
+
+func (r neatRule) apply(obj map[string]any) {
+	if r.ifValue == "" && r.unlessValue == "" {
+		removeNestedField(obj, r.path...)
+		return
+	}
+	fieldPathFor(r.path...).walk(obj, func(m map[string]any, key string, items []int) {
+		if items != nil {
+			return
+		}
+		v, ok := m[key].(string)
+		if !ok {
+			return
+		}
+		if (r.ifValue != "" && v == r.ifValue) || (r.unlessValue != "" && v != r.unlessValue) {
+			delete(m, key)
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package types_test
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+const neatPod = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+  finalizers: [example.com/cleanup]
+  managedFields:
+    - manager: kubectl
+spec:
+  nodeName: node-1
+  serviceAccount: default
+  serviceAccountName: default
+  containers:
+    - name: app
+      terminationMessagePath: /dev/termination-log
+      terminationMessagePolicy: FallbackToLogsOnError
+      volumeMounts:
+        - name: data
+          mountPath: /data
+        - name: kube-api-access-x7k2p
+          mountPath: /var/run/secrets/kubernetes.io/serviceaccount
+  volumes:
+    - name: data
+      emptyDir: {}
+    - name: kube-api-access-x7k2p
+      projected: {}
+`
+
+func TestConfig_NeatFields(t *testing.T) {
+	t.Run("should strip the pod", func(t *testing.T) {
+		config, _ := setupConfig()
+		config.Neat = true
+		res := &types.GroupResource{APIResource: metav1.APIResource{Kind: "Pod"}}
+		us := unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(neatPod), &us.Object); err != nil {
+			t.Fatal(err)
+		}
+
+		config.NeatFields(res, us)
+
+		expected := `apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+  - name: app
+    terminationMessagePolicy: FallbackToLogsOnError
+    volumeMounts:
+    - mountPath: /data
+      name: data
+  serviceAccountName: default
+  volumes:
+  - emptyDir: {}
+    name: data
+`
+		b, _ := yaml.Marshal(us.Object)
+		if string(b) != expected {
+			t.Errorf("expected\n%s\ngot\n%s", expected, b)
+		}
+	})
+
+	for _, tt := range []struct {
+		name      string
+		clusterIP string
+		expected  bool
+	}{
+		{name: "should strip the cluster ip", clusterIP: "10.0.0.1", expected: false},
+		{name: "should keep the headless cluster ip", clusterIP: "None", expected: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Neat = true
+			res := &types.GroupResource{APIResource: metav1.APIResource{Kind: "Service"}}
+			us := unstructured.Unstructured{Object: map[string]any{
+				"spec": map[string]any{"clusterIP": tt.clusterIP, "clusterIPs": []any{tt.clusterIP}},
+			}}
+
+			config.NeatFields(res, us)
+
+			if _, ok := us.Object["spec"].(map[string]any)["clusterIP"]; ok != tt.expected {
+				t.Errorf("expected clusterIP present=%v, got %v", tt.expected, us.Object)
+			}
+		})
+	}
+
+	t.Run("should strip nothing if disabled", func(t *testing.T) {
+		config, _ := setupConfig()
+		res := &types.GroupResource{APIResource: metav1.APIResource{Kind: "PersistentVolume"}}
+		us := unstructured.Unstructured{Object: map[string]any{"spec": map[string]any{"claimRef": map[string]any{}}}}
+		config.NeatFields(res, us)
+		if _, ok := us.Object["spec"].(map[string]any)["claimRef"]; !ok {
+			t.Error("expected claimRef to be kept")
+		}
+	})
+}
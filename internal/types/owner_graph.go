@@ -0,0 +1,221 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	amtypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// OwnerGraphDOT write the owner graph in graphviz dot format.
+	OwnerGraphDOT = "dot"
+	// OwnerGraphJSON write the owner graph as json.
+	OwnerGraphJSON = "json"
+
+	// AnyOwner matches owners of any kind.
+	AnyOwner = AllKinds
+
+	// OwnerGraphFileName the file name of the owner graph without extension.
+	OwnerGraphFileName = "owner-graph"
+
+	ownerGraphAPIVersion = "kubexporter.io/v1"
+	ownerGraphKind       = "OwnerGraph"
+)
+
+// OwnerReferences owner reference rules and graph output.
+type OwnerReferences struct {
+	Transitive     bool     `docs:"Follow the whole owner chain instead of only the direct owners"         json:"transitive"      yaml:"transitive"`
+	ControllerOnly bool     `docs:"Only follow owner references with controller=true"                      json:"controllerOnly"  yaml:"controllerOnly"`
+	ExcludeOwnedBy []string `docs:"Exclude instances owned by one of these kinds ('*' for any owner kind)" json:"excludeOwnedBy"  yaml:"excludeOwnedBy"`
+	Graph          []string `docs:"Write the owner graph into the target directory dot|json"               docs-cli:"owner-graph" json:"graph"          yaml:"graph"`
+}
+
+func (o OwnerReferences) follows(ref metav1.OwnerReference) bool {
+	return !o.ControllerOnly || (ref.Controller != nil && *ref.Controller)
+}
+
+// OwnerLookup fetches an owner that was not part of the export.
+// The namespace is the namespace of the dependent, cluster-scoped owners are expected to ignore it.
+type OwnerLookup func(ref metav1.OwnerReference, namespace string) (*unstructured.Unstructured, error)
+
+// OwnerNode a node of the owner graph.
+type OwnerNode struct {
+	UID        amtypes.UID             `json:"uid"`
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Namespace  string                  `json:"namespace,omitempty"`
+	Name       string                  `json:"name"`
+	Exported   bool                    `json:"exported"`
+	Missing    bool                    `json:"missing,omitempty"`
+	Owners     []metav1.OwnerReference `json:"owners,omitempty"`
+}
+
+func (n *OwnerNode) label() string {
+	name := n.Name
+	if n.Namespace != "" {
+		name = n.Namespace + "/" + name
+	}
+	return n.Kind + "\n" + name
+}
+
+// OwnerGraph the ownership graph of the exported resources and their owners.
+type OwnerGraph struct {
+	// Lookup is used to resolve owners that were not (yet) seen during the export.
+	Lookup OwnerLookup
+
+	mu    sync.Mutex
+	nodes map[amtypes.UID]*OwnerNode
+}
+
+// NewOwnerGraph create a new owner graph.
+func NewOwnerGraph() *OwnerGraph {
+	return &OwnerGraph{nodes: make(map[amtypes.UID]*OwnerNode)}
+}
+
+// Add a resource to the graph.
+func (g *OwnerGraph) Add(us *unstructured.Unstructured, exported bool) {
+	if us.GetUID() == "" {
+		return
+	}
+	n := nodeOf(us)
+	n.Exported = exported
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes[n.UID] = n
+}
+
+// Owner resolves the owner of the given reference, owners not seen before are fetched with Lookup.
+// Owners that can not be resolved are added as missing nodes without owners.
+func (g *OwnerGraph) Owner(ref metav1.OwnerReference, namespace string) *OwnerNode {
+	n := &OwnerNode{
+		UID:        ref.UID,
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		Missing:    true,
+	}
+	if ref.UID == "" {
+		return n
+	}
+
+	g.mu.Lock()
+	existing, ok := g.nodes[ref.UID]
+	g.mu.Unlock()
+	if ok {
+		return existing
+	}
+
+	if g.Lookup != nil {
+		// a recreated owner with the same name is not the owner anymore
+		if us, err := g.Lookup(ref, namespace); err == nil && us != nil && us.GetUID() == ref.UID {
+			n = nodeOf(us)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if existing, ok := g.nodes[ref.UID]; ok {
+		// added by a worker in the meantime
+		return existing
+	}
+	g.nodes[ref.UID] = n
+	return n
+}
+
+// Nodes returns the nodes sorted by kind, namespace and name.
+// Owners that are only known by reference are added as missing nodes.
+func (g *OwnerGraph) Nodes() []*OwnerNode {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	nodes := make(map[amtypes.UID]*OwnerNode, len(g.nodes))
+	for uid, n := range g.nodes {
+		nodes[uid] = n
+		for _, ref := range n.Owners {
+			if _, ok := g.nodes[ref.UID]; !ok {
+				nodes[ref.UID] = &OwnerNode{
+					UID:        ref.UID,
+					APIVersion: ref.APIVersion,
+					Kind:       ref.Kind,
+					Name:       ref.Name,
+					Namespace:  n.Namespace,
+					Missing:    true,
+				}
+			}
+		}
+	}
+
+	var sorted []*OwnerNode
+	for _, n := range nodes {
+		sorted = append(sorted, n)
+	}
+	slices.SortFunc(sorted, func(a, b *OwnerNode) int {
+		return strings.Compare(
+			strings.Join([]string{a.APIVersion, a.Kind, a.Namespace, a.Name, string(a.UID)}, "/"),
+			strings.Join([]string{b.APIVersion, b.Kind, b.Namespace, b.Name, string(b.UID)}, "/"),
+		)
+	})
+	return sorted
+}
+
+// WriteJSON write the graph as json. The graph has an apiVersion and kind,
+// to be readable as object by the commands working on an export in json format.
+func (g *OwnerGraph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"apiVersion": ownerGraphAPIVersion,
+		"kind":       ownerGraphKind,
+		"nodes":      g.Nodes(),
+	})
+}
+
+// WriteDOT write the graph in graphviz dot format. Edges point from the owner to the dependent,
+// controller references are drawn bold, resources that were not exported dashed.
+func (g *OwnerGraph) WriteDOT(w io.Writer) error {
+	nodes := g.Nodes()
+	var sb strings.Builder
+	sb.WriteString("digraph owners {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, n := range nodes {
+		var style string
+		switch {
+		case n.Missing:
+			style = ", style=dotted"
+		case !n.Exported:
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&sb, "  %q [label=%q%s];\n", n.UID, n.label(), style)
+	}
+	for _, n := range nodes {
+		for _, ref := range n.Owners {
+			var style string
+			if ref.Controller != nil && *ref.Controller {
+				style = " [style=bold]"
+			}
+			fmt.Fprintf(&sb, "  %q -> %q%s;\n", ref.UID, n.UID, style)
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func nodeOf(us *unstructured.Unstructured) *OwnerNode {
+	return &OwnerNode{
+		UID:        us.GetUID(),
+		APIVersion: us.GetAPIVersion(),
+		Kind:       us.GetKind(),
+		Namespace:  us.GetNamespace(),
+		Name:       us.GetName(),
+		Owners:     us.GetOwnerReferences(),
+	}
+}
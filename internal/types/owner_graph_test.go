@@ -0,0 +1,160 @@
+package types_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	amtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func ownedObject(apiVersion, kind, name string, uid amtypes.UID, owners ...metav1.OwnerReference) *unstructured.Unstructured {
+	us := &unstructured.Unstructured{}
+	us.SetAPIVersion(apiVersion)
+	us.SetKind(kind)
+	us.SetNamespace("ns")
+	us.SetName(name)
+	us.SetUID(uid)
+	us.SetOwnerReferences(owners)
+	return us
+}
+
+func ownerRef(us *unstructured.Unstructured, controller bool) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: us.GetAPIVersion(),
+		Kind:       us.GetKind(),
+		Name:       us.GetName(),
+		UID:        us.GetUID(),
+		Controller: new(controller),
+	}
+}
+
+func TestConfig_IsInstanceExcluded_transitive(t *testing.T) {
+	deployment := ownedObject("apps/v1", "Deployment", "app", "d-1")
+	rs := ownedObject("apps/v1", "ReplicaSet", "app-1", "rs-1", ownerRef(deployment, true))
+	pod := ownedObject("v1", "Pod", "app-1-x", "p-1", ownerRef(rs, true))
+	cm := ownedObject("v1", "ConfigMap", "cfg", "cm-1", ownerRef(deployment, false))
+	cluster := map[amtypes.UID]*unstructured.Unstructured{"d-1": deployment, "rs-1": rs}
+
+	tests := []struct {
+		name     string
+		setup    func(c *types.Config)
+		obj      *unstructured.Unstructured
+		expected bool
+	}{
+		{
+			name: "should not be excluded by an excluded grand owner if not transitive",
+			setup: func(c *types.Config) {
+				c.ConsiderOwnerReferences = true
+				c.Excluded.Kinds = []string{"apps.Deployment"}
+			},
+			obj:      pod,
+			expected: false,
+		},
+		{
+			name: "should be excluded by an excluded grand owner if transitive",
+			setup: func(c *types.Config) {
+				c.ConsiderOwnerReferences = true
+				c.Excluded.Kinds = []string{"apps.Deployment"}
+				c.OwnerReferences.Transitive = true
+			},
+			obj:      pod,
+			expected: true,
+		},
+		{
+			name: "should be excluded if owned by any controller",
+			setup: func(c *types.Config) {
+				c.OwnerReferences.ExcludeOwnedBy = []string{types.AnyOwner}
+				c.OwnerReferences.ControllerOnly = true
+			},
+			obj:      pod,
+			expected: true,
+		},
+		{
+			name: "should not be excluded if the owner is no controller",
+			setup: func(c *types.Config) {
+				c.OwnerReferences.ExcludeOwnedBy = []string{types.AnyOwner}
+				c.OwnerReferences.ControllerOnly = true
+			},
+			obj:      cm,
+			expected: false,
+		},
+		{
+			name: "should be excluded if transitively owned by the kind",
+			setup: func(c *types.Config) {
+				c.OwnerReferences.ExcludeOwnedBy = []string{"apps.Deployment"}
+				c.OwnerReferences.Transitive = true
+			},
+			obj:      pod,
+			expected: true,
+		},
+		{
+			name: "should not be excluded if not owned by the kind",
+			setup: func(c *types.Config) {
+				c.OwnerReferences.ExcludeOwnedBy = []string{"apps.StatefulSet"}
+				c.OwnerReferences.Transitive = true
+			},
+			obj:      pod,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, res := setupConfig()
+			config.OwnerGraph().Lookup = func(ref metav1.OwnerReference, _ string) (*unstructured.Unstructured, error) {
+				return cluster[ref.UID], nil
+			}
+			tt.setup(config)
+			if got := config.IsInstanceExcluded(res, *tt.obj); got != tt.expected {
+				t.Errorf("IsInstanceExcluded() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOwnerGraph_Write(t *testing.T) {
+	deployment := ownedObject("apps/v1", "Deployment", "app", "d-1")
+	rs := ownedObject("apps/v1", "ReplicaSet", "app-1", "rs-1", ownerRef(deployment, true))
+	pod := ownedObject("v1", "Pod", "app-1-x", "p-1", ownerRef(rs, true))
+
+	g := types.NewOwnerGraph()
+	g.Add(rs, false)
+	g.Add(pod, true)
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`"d-1" [label="Deployment\nns/app", style=dotted];`,
+		`"rs-1" [label="ReplicaSet\nns/app-1", style=dashed];`,
+		`"p-1" [label="Pod\nns/app-1-x"];`,
+		`"d-1" -> "rs-1" [style=bold];`,
+		`"rs-1" -> "p-1" [style=bold];`,
+	} {
+		if !strings.Contains(dot.String(), s) {
+			t.Errorf("expected dot to contain %s\n%s", s, dot.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := g.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	graph := &struct {
+		Kind  string            `json:"kind"`
+		Nodes []types.OwnerNode `json:"nodes"`
+	}{}
+	if err := json.Unmarshal(js.Bytes(), graph); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Kind != "OwnerGraph" || len(graph.Nodes) != 3 {
+		t.Errorf("unexpected graph %s", js.String())
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// pattern matches names either by glob (e.g. 'team-*') or by a regex enclosed in slashes (e.g. '/^team-(a|b)$/').
+// Values without glob or regex syntax match exactly.
+type pattern struct {
+	raw string
+	re  *regexp.Regexp
+}
+
+func compilePattern(p string) (*pattern, error) {
+	if isRegexPattern(p) {
+		re, err := regexp.Compile(p[1 : len(p)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", p, err)
+		}
+		return &pattern{raw: p, re: re}, nil
+	}
+	if _, err := path.Match(p, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", p, err)
+	}
+	return &pattern{raw: p}, nil
+}
+
+func compilePatterns(ps ...string) ([]*pattern, error) {
+	var patterns []*pattern
+	for _, p := range ps {
+		cp, err := compilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, cp)
+	}
+	return patterns, nil
+}
+
+func (p *pattern) match(value string) bool {
+	if p.re != nil {
+		return p.re.MatchString(value)
+	}
+	ok, _ := path.Match(p.raw, value)
+	return ok
+}
+
+func (p *pattern) String() string {
+	return p.raw
+}
+
+func matchesAny(patterns []*pattern, value string) bool {
+	for _, p := range patterns {
+		if p.match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegexPattern(p string) bool {
+	return len(p) > 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/")
+}
+
+// isPattern returns true if the value is a glob or regex and not a plain name.
+func isPattern(p string) bool {
+	return isRegexPattern(p) || strings.ContainsAny(p, `*?[\`)
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultPluginTimeout default timeout of a single plugin execution.
+const DefaultPluginTimeout = 30 * time.Second
+
+// Plugin an external transformer executable (KRM function style). The instances are written to stdin
+// as ResourceList, the ResourceList read from stdout replaces them, missing instances are dropped.
+type Plugin struct {
+	Name           string           `json:"name"                     yaml:"name"`
+	Exec           string           `json:"exec"                     yaml:"exec"`
+	Args           []string         `json:"args,omitempty"           yaml:"args,omitempty"`
+	Env            []string         `json:"env,omitempty"            yaml:"env,omitempty"`
+	Kinds          []string         `json:"kinds,omitempty"          yaml:"kinds,omitempty"`
+	Batch          bool             `json:"batch,omitempty"          yaml:"batch,omitempty"`
+	Timeout        *metav1.Duration `json:"timeout,omitempty"        yaml:"timeout,omitempty"`
+	FunctionConfig map[string]any   `json:"functionConfig,omitempty" yaml:"functionConfig,omitempty"`
+	DropOnError    bool             `json:"dropOnError,omitempty"    yaml:"dropOnError,omitempty"`
+}
+
+// String returns the name of the plugin or the executable.
+func (p Plugin) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Exec
+}
+
+// GetTimeout returns the configured or the default timeout.
+func (p Plugin) GetTimeout() time.Duration {
+	if p.Timeout == nil || p.Timeout.Duration <= 0 {
+		return DefaultPluginTimeout
+	}
+	return p.Timeout.Duration
+}
+
+// Matches returns true if the plugin applies to the resource, plugins without kinds apply to all resources.
+func (p Plugin) Matches(res *GroupResource) bool {
+	return len(p.Kinds) == 0 || bestKindMatch(p.Kinds, res) > noMatch
+}
+
+// PluginsFor returns the plugins applying to the resource in configured order.
+func (c *Config) PluginsFor(res *GroupResource) []Plugin {
+	var plugins []Plugin
+	for _, p := range c.Plugins {
+		if p.Matches(res) {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+func (c *Config) validatePlugins() error {
+	for i, p := range c.Plugins {
+		if p.Exec == "" {
+			return fmt.Errorf("plugin %d: exec is required", i)
+		}
+		if err := validateKindRules("plugin "+p.String(), p.Kinds...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
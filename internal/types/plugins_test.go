@@ -0,0 +1,47 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func TestConfig_PluginsFor(t *testing.T) {
+	config, res := setupConfig()
+	config.Plugins = []types.Plugin{
+		{Name: "all", Exec: "all"},
+		{Name: "group", Exec: "group", Kinds: []string{"group:group"}},
+		{Name: "other", Exec: "other", Kinds: []string{"Pod"}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	plugins := config.PluginsFor(res)
+	if len(plugins) != 2 || plugins[0].Name != "all" || plugins[1].Name != "group" {
+		t.Errorf("unexpected plugins %v", plugins)
+	}
+}
+
+func TestPlugin_GetTimeout(t *testing.T) {
+	if d := (types.Plugin{}).GetTimeout(); d != types.DefaultPluginTimeout {
+		t.Errorf("expected default timeout, got %s", d)
+	}
+	if d := (types.Plugin{Timeout: &metav1.Duration{Duration: time.Second}}).GetTimeout(); d != time.Second {
+		t.Errorf("expected 1s, got %s", d)
+	}
+}
+
+func TestConfig_Validate_plugins(t *testing.T) {
+	config, _ := setupConfig()
+	config.Plugins = []types.Plugin{{Name: "no exec"}}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for plugin without exec")
+	}
+	config.Plugins = []types.Plugin{{Exec: "foo", Kinds: []string{"/[/"}}}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for invalid kind rule")
+	}
+}
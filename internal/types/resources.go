@@ -15,24 +15,44 @@ type GroupResource struct {
 	APIGroupVersion   string
 	APIResource       metav1.APIResource
 	APIVersion        string
+	Selector          Selector
 	Instances         int
 	ExportedInstances int
+	StrippedFields    int
 	Pages             int
 	ExportedSize      int64
 	Error             string
 	QueryDuration     time.Duration
 	ExportDuration    time.Duration
+
+	UnmappedNamespaceReferences []NamespaceReference
+	// OptInOnly only instances with the opt-in marker are exported, as the kind is excluded.
+	OptInOnly bool
+	// ExcludedBy number of excluded instances per exclusion rule.
+	ExcludedBy map[string]int
+	// Transformed number of changed fields per transform operation.
+	Transformed map[string]int
+	// MaskedValues values masked by value or key patterns.
+	MaskedValues []MaskedValue
 }
 
 // Report generates report rows.
-func (r GroupResource) Report(withSize, withError, withPages bool) []string {
+func (r GroupResource) Report(withSize, withError, withPages, withSelector, withStripped bool) []string {
 	row := []string{
 		r.APIGroup,
 		r.APIVersion,
 		r.APIResource.Kind,
 		strconv.FormatBool(r.APIResource.Namespaced),
+	}
+	if withSelector {
+		row = append(row, r.Selector.String())
+	}
+	row = append(row,
 		strconv.Itoa(r.Instances),
 		strconv.Itoa(r.ExportedInstances),
+	)
+	if withStripped {
+		row = append(row, strconv.Itoa(r.StrippedFields))
 	}
 	if withSize {
 		row = append(row, humanize.Bytes(uint64(r.ExportedSize)))
@@ -48,6 +68,26 @@ func (r GroupResource) Report(withSize, withError, withPages bool) []string {
 	return row
 }
 
+func (r *GroupResource) addExcludedBy(rule string) {
+	if r == nil {
+		return
+	}
+	if r.ExcludedBy == nil {
+		r.ExcludedBy = make(map[string]int)
+	}
+	r.ExcludedBy[rule]++
+}
+
+func (r *GroupResource) addTransformed(op string, n int) {
+	if r == nil {
+		return
+	}
+	if r.Transformed == nil {
+		r.Transformed = make(map[string]int)
+	}
+	r.Transformed[op] += n
+}
+
 // GroupKind get concatenated group and kind.
 func (r GroupResource) GroupKind() string {
 	if r.APIGroup != "" {
@@ -0,0 +1,218 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// serverPopulatedFields are stripped in addition to the fields marked as readOnly by the schema,
+// as the API server does not mark its own fields as readOnly.
+var serverPopulatedFields = [][]string{
+	{"status"},
+	{"metadata", "uid"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "deletionTimestamp"},
+	{"metadata", "deletionGracePeriodSeconds"},
+	{"metadata", "selfLink"},
+	{"metadata", "managedFields"},
+}
+
+// SchemaLookup returns the OpenAPI v3 document of the group version of the resource.
+type SchemaLookup func(res *GroupResource) (*OpenAPIDocument, error)
+
+// OpenAPIDocument the parts of an OpenAPI v3 document required to strip defaults.
+type OpenAPIDocument struct {
+	Components struct {
+		Schemas map[string]*OpenAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// OpenAPISchema the parts of an OpenAPI v3 schema required to strip defaults.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	AdditionalProperties json.RawMessage           `json:"additionalProperties,omitempty"`
+	AllOf                []*OpenAPISchema          `json:"allOf,omitempty"`
+	Default              any                       `json:"default,omitempty"`
+	ReadOnly             bool                      `json:"readOnly,omitempty"`
+	GroupVersionKinds    []struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"x-kubernetes-group-version-kind,omitempty"`
+}
+
+// SchemaDefaults strips fields with default values and server populated fields based on the OpenAPI v3 schema.
+// The schemas are cached per group resource.
+type SchemaDefaults struct {
+	Lookup SchemaLookup
+
+	schemas sync.Map
+}
+
+type resolvedSchema struct {
+	doc    *OpenAPIDocument
+	schema *OpenAPISchema
+	err    error
+}
+
+// StripsDefaults returns true if the defaults of the resource are stripped.
+func (c *Config) StripsDefaults(res *GroupResource) bool {
+	return bestKindMatch(c.StripDefaults, res) > noMatch
+}
+
+// SchemaDefaults get the schema defaults of the export.
+func (c *Config) SchemaDefaults() *SchemaDefaults {
+	c.schemaDefaultsOnce.Do(func() {
+		c.schemaDefaults = &SchemaDefaults{}
+	})
+	return c.schemaDefaults
+}
+
+// StripDefaultFields removes fields equal to the schema default and read-only fields, if enabled for the resource.
+// The number of stripped fields is recorded in the group resource.
+func (c *Config) StripDefaultFields(res *GroupResource, us unstructured.Unstructured) {
+	if !c.StripsDefaults(res) {
+		return
+	}
+	n, err := c.SchemaDefaults().Strip(res, us)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.StrippedFields += n
+}
+
+// Strip removes fields equal to the schema default and read-only fields and returns the number of removed fields.
+func (d *SchemaDefaults) Strip(res *GroupResource, us unstructured.Unstructured) (int, error) {
+	rs := d.schemaFor(res)
+	if rs.err != nil {
+		return 0, rs.err
+	}
+	stripped := 0
+	for _, f := range serverPopulatedFields {
+		if _, ok, _ := unstructured.NestedFieldNoCopy(us.Object, f...); ok {
+			unstructured.RemoveNestedField(us.Object, f...)
+			stripped++
+		}
+	}
+	return stripped + rs.strip(us.Object, rs.schema), nil
+}
+
+func (d *SchemaDefaults) schemaFor(res *GroupResource) *resolvedSchema {
+	key := res.APIGroupVersion + "/" + res.Kind()
+	if rs, ok := d.schemas.Load(key); ok {
+		//nolint:forcetypeassert
+		return rs.(*resolvedSchema)
+	}
+	rs := &resolvedSchema{}
+	if d.Lookup == nil {
+		rs.err = errors.New("schema lookup is not configured")
+	} else if rs.doc, rs.err = d.Lookup(res); rs.err == nil {
+		rs.schema, rs.err = rs.doc.schemaFor(res)
+	}
+	if rs.err != nil {
+		rs.err = fmt.Errorf("schema of %s: %w", res.GroupKind(), rs.err)
+	}
+	d.schemas.Store(key, rs)
+	return rs
+}
+
+// schemaFor returns the schema with the group version kind of the resource.
+func (doc *OpenAPIDocument) schemaFor(res *GroupResource) (*OpenAPISchema, error) {
+	for _, s := range doc.Components.Schemas {
+		for _, gvk := range s.GroupVersionKinds {
+			if gvk.Group == res.APIGroup && gvk.Version == res.APIVersion && gvk.Kind == res.Kind() {
+				return s, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no schema found for version %s", res.APIVersion)
+}
+
+// resolve follows references and merges allOf schemas, as used by the API server for nested types.
+func (rs *resolvedSchema) resolve(s *OpenAPISchema) *OpenAPISchema {
+	for range 10 {
+		if s == nil || s.Ref == "" {
+			break
+		}
+		s = rs.doc.Components.Schemas[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+	}
+	if s == nil || len(s.AllOf) == 0 {
+		return s
+	}
+	merged := *s
+	merged.AllOf = nil
+	for _, sub := range s.AllOf {
+		if r := rs.resolve(sub); r != nil {
+			if merged.Properties == nil {
+				merged.Properties = r.Properties
+			}
+			if merged.Items == nil {
+				merged.Items = r.Items
+			}
+			if merged.AdditionalProperties == nil {
+				merged.AdditionalProperties = r.AdditionalProperties
+			}
+		}
+	}
+	return &merged
+}
+
+func (rs *resolvedSchema) strip(value any, s *OpenAPISchema) int {
+	s = rs.resolve(s)
+	if s == nil {
+		return 0
+	}
+	stripped := 0
+	switch v := value.(type) {
+	case map[string]any:
+		for key, val := range v {
+			prop := rs.resolve(s.property(key))
+			if prop == nil {
+				continue
+			}
+			if prop.ReadOnly || prop.isDefault(val) {
+				delete(v, key)
+				stripped++
+				continue
+			}
+			stripped += rs.strip(val, prop)
+		}
+	case []any:
+		for _, item := range v {
+			stripped += rs.strip(item, s.Items)
+		}
+	}
+	return stripped
+}
+
+// property returns the schema of the property or of additional properties of maps.
+func (s *OpenAPISchema) property(key string) *OpenAPISchema {
+	if p, ok := s.Properties[key]; ok {
+		return p
+	}
+	if len(s.AdditionalProperties) > 0 {
+		ap := &OpenAPISchema{}
+		// additional properties may also be a bool
+		if err := json.Unmarshal(s.AdditionalProperties, ap); err == nil {
+			return ap
+		}
+	}
+	return nil
+}
+
+func (s *OpenAPISchema) isDefault(value any) bool {
+	return s.Default != nil && reflect.DeepEqual(normalizeNumbers(s.Default), normalizeNumbers(value))
+}
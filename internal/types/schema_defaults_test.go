@@ -0,0 +1,267 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const schemaDocument = `{
+  "components": {
+    "schemas": {
+      "io.example.v1.Widget": {
+        "x-kubernetes-group-version-kind": [{"group": "example.io", "version": "v1", "kind": "Widget"}],
+        "properties": {
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/ObjectMeta"}]},
+          "spec": {"$ref": "#/components/schemas/io.example.v1.WidgetSpec"},
+          "status": {"type": "object", "readOnly": true}
+        }
+      },
+      "io.example.v1.WidgetSpec": {
+        "properties": {
+          "replicas": {"type": "integer", "default": 1},
+          "mode": {"type": "string", "default": "auto"},
+          "ports": {"type": "array", "items": {"properties": {"protocol": {"type": "string", "default": "TCP"}}}},
+          "labels": {"type": "object", "additionalProperties": {"type": "string", "default": "x"}}
+        }
+      },
+      "ObjectMeta": {
+        "properties": {
+          "uid": {"type": "string", "description": "UID ... Read-only."},
+          "name": {"type": "string"}
+        }
+      }
+    }
+  }
+}`
+
+func widget() (*GroupResource, unstructured.Unstructured) {
+	res := &GroupResource{
+		APIGroup:        "example.io",
+		APIVersion:      "v1",
+		APIGroupVersion: "example.io/v1",
+	}
+	res.APIResource.Kind = "Widget"
+	res.APIResource.Group = "example.io"
+	us := unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "w", "uid": "1234"},
+		"spec": map[string]any{
+			"replicas": int64(1),
+			"mode":     "manual",
+			"ports":    []any{map[string]any{"port": int64(80), "protocol": "TCP"}},
+			"labels":   map[string]any{"a": "x", "b": "y"},
+		},
+		"status": map[string]any{"ready": true},
+	}}
+	return res, us
+}
+
+func TestStripDefaultFields(t *testing.T) {
+	doc := &OpenAPIDocument{}
+	if err := json.Unmarshal([]byte(schemaDocument), doc); err != nil {
+		t.Fatal(err)
+	}
+	lookups := 0
+	c := &Config{StripDefaults: []string{"example.io.Widget"}}
+	c.SchemaDefaults().Lookup = func(*GroupResource) (*OpenAPIDocument, error) {
+		lookups++
+		return doc, nil
+	}
+
+	res, us := widget()
+	c.StripDefaultFields(res, us)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	if res.StrippedFields != 5 {
+		t.Errorf("expected 5 stripped fields, got %d", res.StrippedFields)
+	}
+	expected := map[string]any{
+		"metadata": map[string]any{"name": "w"},
+		"spec": map[string]any{
+			"mode":   "manual",
+			"ports":  []any{map[string]any{"port": int64(80)}},
+			"labels": map[string]any{"b": "y"},
+		},
+	}
+	if got, _ := json.Marshal(us.Object); string(got) != mustJSON(t, expected) {
+		t.Errorf("unexpected object %s", got)
+	}
+
+	_, us = widget()
+	c.StripDefaultFields(res, us)
+	if lookups != 1 {
+		t.Errorf("expected the schema to be cached, got %d lookups", lookups)
+	}
+
+	other, us := widget()
+	other.APIResource.Kind = "Gadget"
+	c.StripDefaultFields(other, us)
+	if other.StrippedFields != 0 || lookups != 1 {
+		t.Errorf("expected kinds not opted in to be skipped")
+	}
+}
+
+// coreServiceDocument a fragment of the core/v1 OpenAPI v3 document published by the API server.
+const coreServiceDocument = `{
+  "components": {
+    "schemas": {
+      "io.k8s.api.core.v1.Service": {
+        "description": "Service is a named abstraction of software service (for example, mysql) consisting of local port (for example 3306) that the proxy listens on, and the selector that determines which pods will answer requests sent through the proxy.",
+        "properties": {
+          "apiVersion": {"description": "APIVersion defines the versioned schema of this representation of an object.", "type": "string"},
+          "kind": {"description": "Kind is a string value representing the REST resource this object represents.", "type": "string"},
+          "metadata": {
+            "allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}],
+            "default": {},
+            "description": "Standard object's metadata. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#metadata"
+          },
+          "spec": {
+            "allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.ServiceSpec"}],
+            "default": {},
+            "description": "Spec defines the behavior of a service. https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status"
+          },
+          "status": {
+            "allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.ServiceStatus"}],
+            "default": {},
+            "description": "Most recently observed status of the service. Populated by the system. Read-only. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#spec-and-status"
+          }
+        },
+        "type": "object",
+        "x-kubernetes-group-version-kind": [{"group": "", "kind": "Service", "version": "v1"}]
+      },
+      "io.k8s.api.core.v1.ServiceSpec": {
+        "properties": {
+          "ports": {
+            "description": "The list of ports that are exposed by this service.",
+            "items": {"allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.ServicePort"}], "default": {}},
+            "type": "array"
+          },
+          "selector": {
+            "additionalProperties": {"default": "", "type": "string"},
+            "description": "Route service traffic to pods with label keys and values matching this selector.",
+            "type": "object"
+          }
+        },
+        "type": "object"
+      },
+      "io.k8s.api.core.v1.ServicePort": {
+        "properties": {
+          "name": {"description": "The name of this port within the service.", "type": "string"},
+          "port": {"default": 0, "description": "The port that will be exposed by this service.", "format": "int32", "type": "integer"},
+          "protocol": {"default": "TCP", "description": "The IP protocol for this port. Supports \"TCP\", \"UDP\", and \"SCTP\". Default is TCP.", "type": "string"}
+        },
+        "required": ["port"],
+        "type": "object"
+      },
+      "io.k8s.api.core.v1.ServiceStatus": {
+        "properties": {
+          "loadBalancer": {"allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.LoadBalancerStatus"}], "default": {}}
+        },
+        "type": "object"
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+        "properties": {
+          "annotations": {
+            "additionalProperties": {"default": "", "type": "string"},
+            "description": "Annotations is an unstructured key value map stored with a resource that may be set by external tools to store and retrieve arbitrary metadata.",
+            "type": "object"
+          },
+          "creationTimestamp": {
+            "allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.Time"}],
+            "description": "CreationTimestamp is a timestamp representing the server time when this object was created.\n\nPopulated by the system. Read-only. Null for lists."
+          },
+          "name": {"description": "Name must be unique within a namespace. Cannot be updated.", "type": "string"},
+          "namespace": {"description": "Namespace defines the space within which each name must be unique. Cannot be updated.", "type": "string"},
+          "resourceVersion": {"description": "An opaque value that represents the internal version of this object.\n\nPopulated by the system. Read-only. Value must be treated as opaque by clients and .", "type": "string"},
+          "uid": {"description": "UID is the unique in time and space value for this object.\n\nPopulated by the system. Read-only.", "type": "string"}
+        },
+        "type": "object"
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.Time": {
+        "description": "Time is a wrapper around time.Time which supports correct marshaling to YAML and JSON.",
+        "format": "date-time",
+        "type": "string"
+      }
+    }
+  }
+}`
+
+func TestStripDefaultFields_coreSchema(t *testing.T) {
+	doc := &OpenAPIDocument{}
+	if err := json.Unmarshal([]byte(coreServiceDocument), doc); err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{StripDefaults: []string{"Service"}}
+	c.SchemaDefaults().Lookup = func(*GroupResource) (*OpenAPIDocument, error) {
+		return doc, nil
+	}
+
+	res := &GroupResource{APIVersion: "v1", APIGroupVersion: "v1"}
+	res.APIResource.Kind = "Service"
+	us := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]any{
+			"name":              "svc",
+			"namespace":         "ns",
+			"uid":               "1234",
+			"resourceVersion":   "42",
+			"creationTimestamp": "2026-01-01T00:00:00Z",
+			"annotations":       map[string]any{"note": "Read-only."},
+		},
+		"spec": map[string]any{
+			"ports":    []any{map[string]any{"name": "http", "port": int64(80), "protocol": "TCP"}},
+			"selector": map[string]any{"app": "web"},
+		},
+		"status": map[string]any{"loadBalancer": map[string]any{}},
+	}}
+
+	c.StripDefaultFields(res, us)
+	if res.Error != "" {
+		t.Fatal(res.Error)
+	}
+	// status, uid, resourceVersion, creationTimestamp and the protocol default
+	if res.StrippedFields != 5 {
+		t.Errorf("expected 5 stripped fields, got %d", res.StrippedFields)
+	}
+	expected := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]any{
+			"name":        "svc",
+			"namespace":   "ns",
+			"annotations": map[string]any{"note": "Read-only."},
+		},
+		"spec": map[string]any{
+			"ports":    []any{map[string]any{"name": "http", "port": int64(80)}},
+			"selector": map[string]any{"app": "web"},
+		},
+	}
+	if got, _ := json.Marshal(us.Object); string(got) != mustJSON(t, expected) {
+		t.Errorf("unexpected object %s", got)
+	}
+}
+
+func TestStripDefaultFields_error(t *testing.T) {
+	c := &Config{StripDefaults: []string{AllKinds}}
+	c.SchemaDefaults().Lookup = func(*GroupResource) (*OpenAPIDocument, error) {
+		return nil, errors.New("not found")
+	}
+	res, us := widget()
+	c.StripDefaultFields(res, us)
+	if res.Error != "schema of example.io.Widget: not found" {
+		t.Errorf("unexpected error %q", res.Error)
+	}
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,271 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// SealingScopeStrict the SealedSecret can only be decrypted with its name and namespace.
+	SealingScopeStrict = SealingScope("strict")
+	// SealingScopeNamespaceWide the SealedSecret can be renamed within its namespace.
+	SealingScopeNamespaceWide = SealingScope("namespace-wide")
+	// SealingScopeClusterWide the SealedSecret can be renamed and moved to any namespace.
+	SealingScopeClusterWide = SealingScope("cluster-wide")
+
+	// DefaultSealedSecretsControllerNamespace the default namespace of the sealed-secrets controller.
+	DefaultSealedSecretsControllerNamespace = "kube-system"
+	// DefaultSealedSecretsControllerName the default service name of the sealed-secrets controller.
+	DefaultSealedSecretsControllerName = "sealed-secrets-controller"
+
+	sealedSecretAPIVersion = "bitnami.com/v1alpha1"
+	sealedSecretKind       = "SealedSecret"
+
+	annotationNamespaceWide = "sealedsecrets.bitnami.com/namespace-wide"
+	annotationClusterWide   = "sealedsecrets.bitnami.com/cluster-wide"
+
+	// sessionKeyBytes the size of the aes session key of the hybrid encryption.
+	sessionKeyBytes = 32
+)
+
+// SealingScope the scope of a SealedSecret.
+type SealingScope string
+
+// SealedSecrets converts exported Secrets into SealedSecrets of the sealed-secrets controller.
+type SealedSecrets struct {
+	Enabled             bool         `docs:"Export Secrets as SealedSecrets"                                                 json:"enabled"                       yaml:"enabled"`
+	Cert                string       `docs:"The public certificate (PEM) of the sealed-secrets controller"                   json:"cert,omitempty"                yaml:"cert,omitempty"`
+	CertFile            string       `docs:"A file containing the certificate, if no certificate is set it is fetched once"  json:"certFile,omitempty"            yaml:"certFile,omitempty"`
+	ControllerNamespace string       `docs:"The namespace of the sealed-secrets controller to fetch the certificate from"    json:"controllerNamespace,omitempty" yaml:"controllerNamespace,omitempty"`
+	ControllerName      string       `docs:"The service name of the sealed-secrets controller to fetch the certificate from" json:"controllerName,omitempty"      yaml:"controllerName,omitempty"`
+	Scope               SealingScope `docs:"The scope of the SealedSecrets strict|namespace-wide|cluster-wide"               json:"scope,omitempty"               yaml:"scope,omitempty"`
+	key                 *rsa.PublicKey
+}
+
+// FetchesCert returns true if the certificate has to be fetched from the sealed-secrets controller.
+func (s *SealedSecrets) FetchesCert() bool {
+	return s != nil && s.Enabled && s.Cert == "" && s.CertFile == ""
+}
+
+// Setup reads the certificate of enabled SealedSecrets.
+func (s *SealedSecrets) Setup() error {
+	if s == nil || !s.Enabled {
+		return nil
+	}
+	switch s.Scope {
+	case "":
+		s.Scope = SealingScopeStrict
+	case SealingScopeStrict, SealingScopeNamespaceWide, SealingScopeClusterWide:
+	default:
+		return fmt.Errorf("invalid sealing scope %q, must be one of %s|%s|%s",
+			s.Scope, SealingScopeStrict, SealingScopeNamespaceWide, SealingScopeClusterWide)
+	}
+
+	cert := s.Cert
+	if cert == "" && s.CertFile != "" {
+		b, err := os.ReadFile(s.CertFile)
+		if err != nil {
+			return err
+		}
+		cert = string(b)
+	}
+	if cert == "" {
+		return errors.New("sealed secrets need the certificate of the sealed-secrets controller")
+	}
+	var err error
+	s.key, err = parseSealingCert([]byte(cert))
+	return err
+}
+
+// parseSealingCert returns the rsa public key of the first certificate of the PEM data.
+func parseSealingCert(data []byte) (*rsa.PublicKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate found in the sealed secrets certificate")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("the sealed secrets certificate has no rsa public key")
+		}
+		return key, nil
+	}
+}
+
+// converts returns true if the resource is a Secret that is converted into a SealedSecret.
+func (s *SealedSecrets) converts(res *GroupResource) bool {
+	return s != nil && s.Enabled && res.APIGroup == "" && res.Kind() == "Secret"
+}
+
+// Converts returns true if instances of the resource are converted into another kind before writing.
+func (c *Config) Converts(res *GroupResource) bool {
+	return c.SealedSecrets.converts(res)
+}
+
+// ConvertedResource returns the resource of the converted instances, used to generate the file names.
+func (c *Config) ConvertedResource(res *GroupResource) *GroupResource {
+	if !c.Converts(res) {
+		return res
+	}
+	sealed := &unstructured.Unstructured{}
+	sealed.SetAPIVersion(sealedSecretAPIVersion)
+	sealed.SetKind(sealedSecretKind)
+	gr := groupResourceOf(sealed)
+	gr.APIResource.Namespaced = true
+	return gr
+}
+
+// Convert returns the instance converted into the output kind of the resource, or the instance itself.
+func (c *Config) Convert(res *GroupResource, us *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if !c.Converts(res) {
+		return us, nil
+	}
+	return c.SealedSecrets.seal(us)
+}
+
+// scopeOf returns the scope of the Secret, the scope annotations of the Secret take precedence over the config.
+func (s *SealedSecrets) scopeOf(us *unstructured.Unstructured) SealingScope {
+	annotations := us.GetAnnotations()
+	switch {
+	case annotations[annotationClusterWide] == "true":
+		return SealingScopeClusterWide
+	case annotations[annotationNamespaceWide] == "true":
+		return SealingScopeNamespaceWide
+	}
+	return s.Scope
+}
+
+// seal converts the Secret into a SealedSecret with the values encrypted for the controller certificate.
+func (s *SealedSecrets) seal(us *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if s.key == nil {
+		return nil, errors.New("sealed secrets are not set up")
+	}
+	data, err := secretData(us)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := s.scopeOf(us)
+	label := sealingLabel(scope, us.GetNamespace(), us.GetName())
+	encryptedData := make(map[string]any, len(data))
+	for key, value := range data {
+		ciphertext, err := hybridEncrypt(s.key, value, label)
+		if err != nil {
+			return nil, err
+		}
+		encryptedData[key] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	template := map[string]any{}
+	if md, ok := us.Object["metadata"].(map[string]any); ok {
+		template["metadata"] = runtime.DeepCopyJSON(md)
+	}
+	for _, field := range []string{"type", "immutable"} {
+		if v, ok := us.Object[field]; ok {
+			template[field] = v
+		}
+	}
+
+	sealed := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"encryptedData": encryptedData,
+			"template":      template,
+		},
+	}}
+	sealed.SetAPIVersion(sealedSecretAPIVersion)
+	sealed.SetKind(sealedSecretKind)
+	sealed.SetName(us.GetName())
+	sealed.SetNamespace(us.GetNamespace())
+	switch scope {
+	case SealingScopeNamespaceWide:
+		sealed.SetAnnotations(map[string]string{annotationNamespaceWide: "true"})
+	case SealingScopeClusterWide:
+		sealed.SetAnnotations(map[string]string{annotationClusterWide: "true"})
+	}
+	return sealed, nil
+}
+
+// secretData returns the decoded data of the Secret, stringData takes precedence as on the api server.
+func secretData(us *unstructured.Unstructured) (map[string][]byte, error) {
+	data := make(map[string][]byte)
+	encoded, _, err := unstructured.NestedStringMap(us.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range encoded {
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data %q of secret %s/%s: %w", key, us.GetNamespace(), us.GetName(), err)
+		}
+		data[key] = b
+	}
+	plain, _, err := unstructured.NestedStringMap(us.Object, "stringData")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range plain {
+		data[key] = []byte(value)
+	}
+	return data, nil
+}
+
+// sealingLabel returns the label binding the values to the scope of the SealedSecret.
+func sealingLabel(scope SealingScope, namespace, name string) []byte {
+	switch scope {
+	case SealingScopeClusterWide:
+		return nil
+	case SealingScopeNamespaceWide:
+		return []byte(namespace)
+	default:
+		return []byte(namespace + "/" + name)
+	}
+}
+
+// hybridEncrypt encrypts the plaintext as the sealed-secrets controller expects it: a random aes session key
+// encrypted with rsa-oaep, prefixed by its length, followed by the plaintext sealed with the session key.
+func hybridEncrypt(key *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := binary.BigEndian.AppendUint16(nil, uint16(len(rsaCiphertext))) //nolint:gosec // rsa ciphertexts are small
+	ciphertext = append(ciphertext, rsaCiphertext...)
+
+	// the session key is used only once, so the nonce can be zero
+	zeroNonce := make([]byte, gcm.NonceSize())
+	return gcm.Seal(ciphertext, zeroNonce, plaintext, nil), nil
+}
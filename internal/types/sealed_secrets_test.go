@@ -0,0 +1,185 @@
+package types
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSealedSecrets_Setup(t *testing.T) {
+	_, cert := sealingCert(t)
+	tests := []struct {
+		name    string
+		ss      *SealedSecrets
+		wantErr bool
+	}{
+		{name: "disabled needs no cert", ss: &SealedSecrets{}},
+		{name: "cert", ss: &SealedSecrets{Enabled: true, Cert: cert}},
+		{name: "missing cert", ss: &SealedSecrets{Enabled: true}, wantErr: true},
+		{name: "invalid cert", ss: &SealedSecrets{Enabled: true, Cert: "invalid"}, wantErr: true},
+		{name: "invalid scope", ss: &SealedSecrets{Enabled: true, Cert: cert, Scope: "global"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.ss.Setup(); (err != nil) != tt.wantErr {
+				t.Errorf("Setup() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Convert_sealedSecret(t *testing.T) {
+	priv, cert := sealingCert(t)
+	config := NewConfig(nil, printFlags())
+	config.SealedSecrets.Enabled = true
+	config.SealedSecrets.Cert = cert
+	if err := config.SealedSecrets.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	secret := metav1.APIResource{Kind: "Secret", Namespaced: true}
+	res := &GroupResource{APIVersion: "v1", APIGroupVersion: "v1", APIResource: secret}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		label       string
+		annotation  string
+	}{
+		{name: "strict", label: "ns/s"},
+		{
+			name:        "namespace-wide",
+			annotations: map[string]string{annotationNamespaceWide: "true"},
+			label:       "ns",
+			annotation:  annotationNamespaceWide,
+		},
+		{
+			name:        "cluster-wide",
+			annotations: map[string]string{annotationClusterWide: "true"},
+			label:       "",
+			annotation:  annotationClusterWide,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"type":       "Opaque",
+				"data":       map[string]any{"a": base64.StdEncoding.EncodeToString([]byte("value-a"))},
+				"stringData": map[string]any{"b": "value-b"},
+			}}
+			us.SetName("s")
+			us.SetNamespace("ns")
+			us.SetLabels(map[string]string{"app": "x"})
+			us.SetAnnotations(tt.annotations)
+
+			sealed, err := config.Convert(res, us)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sealed.GetAPIVersion() != "bitnami.com/v1alpha1" || sealed.GetKind() != "SealedSecret" {
+				t.Errorf("unexpected type %s %s", sealed.GetAPIVersion(), sealed.GetKind())
+			}
+			if tt.annotation != "" && sealed.GetAnnotations()[tt.annotation] != "true" {
+				t.Errorf("expected annotation %q, got %v", tt.annotation, sealed.GetAnnotations())
+			}
+			if v, _, _ := unstructured.NestedString(sealed.Object, "spec", "template", "type"); v != "Opaque" {
+				t.Errorf("expected template type Opaque, got %q", v)
+			}
+			if v, _, _ := unstructured.NestedString(sealed.Object, "spec", "template", "metadata", "labels", "app"); v != "x" {
+				t.Errorf("expected template label app=x, got %q", v)
+			}
+
+			encrypted, _, _ := unstructured.NestedStringMap(sealed.Object, "spec", "encryptedData")
+			for key, want := range map[string]string{"a": "value-a", "b": "value-b"} {
+				if got := hybridDecrypt(t, priv, encrypted[key], tt.label); got != want {
+					t.Errorf("expected %q for %q, got %q", want, key, got)
+				}
+			}
+		})
+	}
+
+	t.Run("file name", func(t *testing.T) {
+		us := &unstructured.Unstructured{}
+		us.SetName("s")
+		us.SetNamespace("ns")
+		name, err := config.FileName(config.ConvertedResource(res), us, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "ns/bitnami.com.SealedSecret.s.yaml" {
+			t.Errorf("unexpected file name %q", name)
+		}
+	})
+
+	t.Run("other kinds are not converted", func(t *testing.T) {
+		other := &GroupResource{APIGroup: "example.com", APIResource: secret}
+		us := &unstructured.Unstructured{}
+		if config.Converts(other) || config.ConvertedResource(other) != other {
+			t.Error("expected no conversion of other groups")
+		}
+		if c, _ := config.Convert(other, us); c != us {
+			t.Error("expected the instance itself")
+		}
+	})
+}
+
+// sealingCert returns a private key and the PEM encoded self-signed certificate of its public key.
+func sealingCert(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+// hybridDecrypt decrypts the value as the sealed-secrets controller does.
+func hybridDecrypt(t *testing.T, priv *rsa.PrivateKey, value, label string) string {
+	t.Helper()
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, ciphertext[2:2+rsaLen], []byte(label))
+	if err != nil {
+		t.Fatalf("decrypt session key with label %q: %v", label, err)
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, make([]byte, gcm.NonceSize()), ciphertext[2+rsaLen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(plaintext)
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Selector server-side label and field selector.
+type Selector struct {
+	LabelSelector string `docs:"Label selector e.g. 'app=foo,tier!=db'"   docs-cli:"selector"       json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+	FieldSelector string `docs:"Field selector e.g. 'metadata.name!=foo'" docs-cli:"field-selector" json:"fieldSelector,omitempty" yaml:"fieldSelector,omitempty"`
+}
+
+// IsEmpty returns true if no selector is defined.
+func (s Selector) IsEmpty() bool {
+	return s.LabelSelector == "" && s.FieldSelector == ""
+}
+
+func (s Selector) String() string {
+	var parts []string
+	if s.LabelSelector != "" {
+		parts = append(parts, "labels: "+s.LabelSelector)
+	}
+	if s.FieldSelector != "" {
+		parts = append(parts, "fields: "+s.FieldSelector)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// and combines both selectors, all requirements have to match.
+func (s Selector) and(other Selector) Selector {
+	return Selector{
+		LabelSelector: joinRequirements(s.LabelSelector, other.LabelSelector),
+		FieldSelector: joinRequirements(s.FieldSelector, other.FieldSelector),
+	}
+}
+
+func (s Selector) validate() error {
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", s.LabelSelector, err)
+	}
+	if _, err := fields.ParseSelector(s.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector %q: %w", s.FieldSelector, err)
+	}
+	return nil
+}
+
+func joinRequirements(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return a + "," + b
+}
+
+// SelectorFor returns the global selector combined with the kind specific selectors matching the resource.
+func (c *Config) SelectorFor(res *GroupResource) Selector {
+	sel := c.Selector
+	for _, key := range matchingKindKeys(c.KindSelectors, res) {
+		sel = sel.and(c.KindSelectors[key])
+	}
+	return sel
+}
+
+// HasSelectors returns true if a global or kind specific selector is defined.
+func (c *Config) HasSelectors() bool {
+	if !c.Selector.IsEmpty() {
+		return true
+	}
+	for _, s := range c.KindSelectors {
+		if !s.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) validateSelectors() error {
+	if err := c.Selector.validate(); err != nil {
+		return err
+	}
+	for kind, s := range c.KindSelectors {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("kind %s: %w", kind, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+func TestConfig_SelectorFor(t *testing.T) {
+	config, res := setupConfig()
+	if config.HasSelectors() {
+		t.Error("expected no selectors")
+	}
+
+	config.Selector = types.Selector{LabelSelector: "app=foo"}
+	config.KindSelectors = map[string]types.Selector{
+		res.GroupKind(): {LabelSelector: "tier!=db", FieldSelector: "metadata.name!=bar"},
+	}
+	if !config.HasSelectors() {
+		t.Error("expected selectors")
+	}
+
+	sel := config.SelectorFor(res)
+	if sel.LabelSelector != "app=foo,tier!=db" {
+		t.Errorf("label selector = %q, want %q", sel.LabelSelector, "app=foo,tier!=db")
+	}
+	if sel.FieldSelector != "metadata.name!=bar" {
+		t.Errorf("field selector = %q, want %q", sel.FieldSelector, "metadata.name!=bar")
+	}
+	if sel.String() != "labels: app=foo,tier!=db; fields: metadata.name!=bar" {
+		t.Errorf("unexpected string %q", sel.String())
+	}
+
+	other := &types.GroupResource{}
+	other.APIResource.Kind = "Other"
+	if sel := config.SelectorFor(other); sel.LabelSelector != "app=foo" || sel.FieldSelector != "" {
+		t.Errorf("unexpected selector %v", sel)
+	}
+}
+
+func TestConfig_Validate_selectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector types.Selector
+		wantErr  bool
+	}{
+		{name: "valid", selector: types.Selector{LabelSelector: "app in (a,b)", FieldSelector: "status.phase=Running"}},
+		{name: "invalid label selector", selector: types.Selector{LabelSelector: "app in a"}, wantErr: true},
+		{name: "invalid field selector", selector: types.Selector{FieldSelector: "status.phase~Running"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.KindSelectors = map[string]types.Selector{"Pod": tt.selector}
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// TransformOp transformation operation.
+type TransformOp string
+
+const (
+	// TransformSet set the field to the value, missing parent fields are created.
+	TransformSet = TransformOp("set")
+	// TransformDelete delete the field.
+	TransformDelete = TransformOp("delete")
+	// TransformRename rename the key of the field within its parent.
+	TransformRename = TransformOp("rename")
+	// TransformReplace replace the regex in string values of the field.
+	TransformReplace = TransformOp("replace")
+	// TransformPatch apply a JSON patch (RFC 6902) to the instance.
+	TransformPatch = TransformOp("patch")
+)
+
+var jsonPatchOps = []string{"add", "remove", "replace", "move", "copy", "test"}
+
+// compiled transformation regexes and patches are immutable and therefore shared.
+var (
+	transformRegexes sync.Map
+	transformPatches sync.Map
+)
+
+// Transforms map kinds to transformations.
+type Transforms map[string][]TransformOperation
+
+// TransformOperation a single transformation of an instance.
+type TransformOperation struct {
+	Op          TransformOp      `json:"op"                    yaml:"op"`
+	Path        []string         `json:"path,omitempty"        yaml:"path,omitempty"`
+	Value       any              `json:"value,omitempty"       yaml:"value,omitempty"`
+	To          string           `json:"to,omitempty"          yaml:"to,omitempty"`
+	Regex       string           `json:"regex,omitempty"       yaml:"regex,omitempty"`
+	Replacement string           `json:"replacement,omitempty" yaml:"replacement,omitempty"`
+	Patch       []map[string]any `json:"patch,omitempty"       yaml:"patch,omitempty"`
+}
+
+func (o TransformOperation) String() string {
+	if o.Op == TransformPatch {
+		return string(o.Op)
+	}
+	return fmt.Sprintf("%s %s", o.Op, strings.Join(o.Path, "."))
+}
+
+// TransformFields applies the transformations of all kind rules matching the resource,
+// starting with the least specific rule. The number of changes is recorded per operation.
+func (c *Config) TransformFields(res *GroupResource, us unstructured.Unstructured) {
+	for _, key := range matchingKindKeys(c.Transform, res) {
+		for _, op := range c.Transform[key] {
+			if n := op.apply(us.Object); n > 0 {
+				res.addTransformed(op.String(), n)
+			}
+		}
+	}
+}
+
+// apply the operation to the object and return the number of changed fields.
+func (o TransformOperation) apply(obj map[string]any) int {
+	switch o.Op {
+	case TransformSet:
+		return setNestedField(obj, normalizeNumbers(o.Value), o.Path...)
+	case TransformDelete:
+		return removeNestedField(obj, o.Path...)
+	case TransformRename:
+		return renameNestedField(obj, o.To, o.Path...)
+	case TransformReplace:
+		re, err := transformRegex(o.Regex)
+		if err != nil {
+			return 0
+		}
+		return replaceNestedField(obj, re, o.Replacement, o.Path...)
+	case TransformPatch:
+		return patchObject(obj, o.Patch)
+	}
+	return 0
+}
+
+// setNestedField sets the value, plain paths create missing parent fields,
+// paths with patterns or list selectors only set existing fields.
+func setNestedField(obj map[string]any, value any, fields ...string) int {
+	path := fieldPathFor(fields...)
+	if plain, ok := path.plain(); ok {
+		if current, found, _ := unstructured.NestedFieldNoCopy(obj, plain...); found && reflect.DeepEqual(current, value) {
+			return 0
+		}
+		if err := unstructured.SetNestedField(obj, runtime.DeepCopyJSONValue(value), plain...); err == nil {
+			return 1
+		}
+	}
+	changed := 0
+	path.walk(obj, func(m map[string]any, key string, items []int) {
+		if items == nil {
+			if !reflect.DeepEqual(m[key], value) {
+				m[key] = runtime.DeepCopyJSONValue(value)
+				changed++
+			}
+			return
+		}
+		//nolint:forcetypeassert
+		list := m[key].([]any)
+		for _, i := range items {
+			if !reflect.DeepEqual(list[i], value) {
+				list[i] = runtime.DeepCopyJSONValue(value)
+				changed++
+			}
+		}
+	})
+	return changed
+}
+
+// renameNestedField renames the key of the field within its parent map.
+func renameNestedField(obj map[string]any, to string, fields ...string) int {
+	changed := 0
+	fieldPathFor(fields...).walk(obj, func(m map[string]any, key string, items []int) {
+		if items != nil || key == to {
+			return
+		}
+		m[to] = m[key]
+		delete(m, key)
+		changed++
+	})
+	return changed
+}
+
+// replaceNestedField replaces the regex in string values, string values of maps and lists.
+func replaceNestedField(obj map[string]any, re *regexp.Regexp, replacement string, fields ...string) int {
+	changed := 0
+	replace := func(v any) any {
+		if s, ok := v.(string); ok {
+			if r := re.ReplaceAllString(s, replacement); r != s {
+				changed++
+				return r
+			}
+		}
+		return v
+	}
+	fieldPathFor(fields...).walk(obj, func(m map[string]any, key string, items []int) {
+		var list []any
+		switch e := m[key].(type) {
+		case map[string]any:
+			for k := range e {
+				e[k] = replace(e[k])
+			}
+			return
+		case []any:
+			list = e
+		default:
+			m[key] = replace(e)
+			return
+		}
+		if items == nil {
+			for i := range list {
+				list[i] = replace(list[i])
+			}
+			return
+		}
+		for _, i := range items {
+			list[i] = replace(list[i])
+		}
+	})
+	return changed
+}
+
+// patchObject applies the JSON patch, patches that fail (e.g. a failing 'test' operation) do not change the object.
+func patchObject(obj map[string]any, ops []map[string]any) int {
+	patch, err := transformPatch(ops)
+	if err != nil {
+		return 0
+	}
+	doc, err := json.Marshal(obj)
+	if err != nil {
+		return 0
+	}
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		return 0
+	}
+	result := map[string]any{}
+	if err := utiljson.Unmarshal(patched, &result); err != nil {
+		return 0
+	}
+	if reflect.DeepEqual(obj, result) {
+		return 0
+	}
+	clear(obj)
+	maps.Copy(obj, result)
+	return 1
+}
+
+func transformRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := transformRegexes.Load(expr); ok {
+		//nolint:forcetypeassert
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	transformRegexes.Store(expr, re)
+	return re, nil
+}
+
+func transformPatch(ops []map[string]any) (jsonpatch.Patch, error) {
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := transformPatches.Load(string(b)); ok {
+		//nolint:forcetypeassert
+		return p.(jsonpatch.Patch), nil
+	}
+	p, err := jsonpatch.DecodePatch(b)
+	if err != nil {
+		return nil, err
+	}
+	transformPatches.Store(string(b), p)
+	return p, nil
+}
+
+// normalizeNumbers converts integral float values from the config to int64, as used by unstructured objects.
+func normalizeNumbers(v any) any {
+	switch e := v.(type) {
+	case float64:
+		if e == float64(int64(e)) {
+			return int64(e)
+		}
+	case map[string]any:
+		m := make(map[string]any, len(e))
+		for k, val := range e {
+			m[k] = normalizeNumbers(val)
+		}
+		return m
+	case []any:
+		l := make([]any, len(e))
+		for i, val := range e {
+			l[i] = normalizeNumbers(val)
+		}
+		return l
+	}
+	return v
+}
+
+func (o TransformOperation) validate() error {
+	if o.Op == TransformPatch {
+		if len(o.Patch) == 0 {
+			return errors.New("patch requires at least one operation")
+		}
+		for _, op := range o.Patch {
+			if !slices.Contains(jsonPatchOps, fmt.Sprint(op["op"])) {
+				return fmt.Errorf("unsupported patch operation %q", op["op"])
+			}
+			if _, ok := op["path"].(string); !ok {
+				return fmt.Errorf("patch operation %q requires a path", op["op"])
+			}
+		}
+		_, err := transformPatch(o.Patch)
+		return err
+	}
+	if _, err := parseFieldPath(o.Path...); err != nil {
+		return err
+	}
+	switch o.Op {
+	case TransformSet, TransformDelete:
+	case TransformRename:
+		if o.To == "" {
+			return errors.New("rename requires 'to'")
+		}
+	case TransformReplace:
+		if _, err := transformRegex(o.Regex); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported operation %q", o.Op)
+	}
+	return nil
+}
+
+func (c *Config) validateTransform() error {
+	for kind, ops := range c.Transform {
+		for i, op := range ops {
+			if err := op.validate(); err != nil {
+				return fmt.Errorf("invalid transform %d of kind %s: %w", i, kind, err)
+			}
+		}
+	}
+	return nil
+}
+
+// HasTransform returns true if transformations are configured.
+func (c *Config) HasTransform() bool {
+	return len(c.Transform) > 0
+}
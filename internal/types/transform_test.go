@@ -0,0 +1,203 @@
+package types_test
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bakito/kubexporter/internal/types"
+)
+
+const transformObject = `
+metadata:
+  name: app
+  labels:
+    app: shop
+    tier: web
+spec:
+  replicas: 3
+  rules:
+    - host: shop.apps.example.com
+    - host: api.apps.example.com
+  containers:
+    - name: app
+      image: registry.example.com/shop/app:1.0
+    - name: sidecar
+      image: registry.example.com/proxy:2.0
+`
+
+func TestConfig_TransformFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform string
+		path      []string
+		expected  any
+		changes   map[string]int
+	}{
+		{
+			name: "should set a field",
+			transform: `
+- op: set
+  path: [spec, replicas]
+  value: 0`,
+			path:     []string{"spec", "replicas"},
+			expected: int64(0),
+			changes:  map[string]int{"set spec.replicas": 1},
+		},
+		{
+			name: "should create missing parent fields",
+			transform: `
+- op: set
+  path: [metadata, annotations, restored]
+  value: "true"`,
+			path:     []string{"metadata", "annotations", "restored"},
+			expected: "true",
+			changes:  map[string]int{"set metadata.annotations.restored": 1},
+		},
+		{
+			name: "should delete a field",
+			transform: `
+- op: delete
+  path: [metadata, labels, tier]`,
+			path:     []string{"metadata", "labels"},
+			expected: map[string]any{"app": "shop"},
+			changes:  map[string]int{"delete metadata.labels.tier": 1},
+		},
+		{
+			name: "should rename a label",
+			transform: `
+- op: rename
+  path: [metadata, labels, app]
+  to: app.kubernetes.io/name`,
+			path:     []string{"metadata", "labels"},
+			expected: map[string]any{"app.kubernetes.io/name": "shop", "tier": "web"},
+			changes:  map[string]int{"rename metadata.labels.app": 1},
+		},
+		{
+			name: "should replace the image registry",
+			transform: `
+- op: replace
+  path: ['$.spec.containers[*].image']
+  regex: ^registry\.example\.com/
+  replacement: mirror.local/`,
+			path: []string{"spec", "containers"},
+			expected: []any{
+				map[string]any{"name": "app", "image": "mirror.local/shop/app:1.0"},
+				map[string]any{"name": "sidecar", "image": "mirror.local/proxy:2.0"},
+			},
+			changes: map[string]int{"replace $.spec.containers[*].image": 2},
+		},
+		{
+			name: "should strip a domain suffix",
+			transform: `
+- op: replace
+  path: [spec, rules, host]
+  regex: \.apps\.example\.com$`,
+			path: []string{"spec", "rules"},
+			expected: []any{
+				map[string]any{"host": "shop"},
+				map[string]any{"host": "api"},
+			},
+			changes: map[string]int{"replace spec.rules.host": 2},
+		},
+		{
+			name: "should apply a json patch",
+			transform: `
+- op: patch
+  patch:
+    - op: replace
+      path: /spec/replicas
+      value: 1`,
+			path:     []string{"spec", "replicas"},
+			expected: int64(1),
+			changes:  map[string]int{"patch": 1},
+		},
+		{
+			name: "should not count a failing json patch",
+			transform: `
+- op: patch
+  patch:
+    - op: test
+      path: /spec/replicas
+      value: 5
+    - op: replace
+      path: /spec/replicas
+      value: 1`,
+			path:     []string{"spec", "replicas"},
+			expected: int64(3),
+		},
+		{
+			name: "should not count unchanged values",
+			transform: `
+- op: set
+  path: [spec, replicas]
+  value: 3`,
+			path:     []string{"spec", "replicas"},
+			expected: int64(3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, res := setupConfig()
+			var ops []types.TransformOperation
+			if err := yaml.Unmarshal([]byte(tt.transform), &ops); err != nil {
+				t.Fatal(err)
+			}
+			config.Transform = types.Transforms{"*": ops}
+			if err := config.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			us := unstructured.Unstructured{}
+			if err := yaml.Unmarshal([]byte(transformObject), &us.Object); err != nil {
+				t.Fatal(err)
+			}
+			// unstructured objects from the API use int64
+			_ = unstructured.SetNestedField(us.Object, int64(3), "spec", "replicas")
+
+			config.TransformFields(res, us)
+
+			got, _, _ := unstructured.NestedFieldNoCopy(us.Object, tt.path...)
+			if !equality(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+			if len(res.Transformed) != len(tt.changes) {
+				t.Errorf("expected changes %v, got %v", tt.changes, res.Transformed)
+			}
+			for op, n := range tt.changes {
+				if res.Transformed[op] != n {
+					t.Errorf("expected %d changes for %q, got %v", n, op, res.Transformed)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_transform(t *testing.T) {
+	for name, op := range map[string]types.TransformOperation{
+		"unknown op":      {Op: "move", Path: []string{"spec"}},
+		"missing path":    {Op: types.TransformDelete},
+		"rename no to":    {Op: types.TransformRename, Path: []string{"spec"}},
+		"invalid regex":   {Op: types.TransformReplace, Path: []string{"spec"}, Regex: "("},
+		"empty patch":     {Op: types.TransformPatch},
+		"invalid patch":   {Op: types.TransformPatch, Patch: []map[string]any{{"op": "foo"}}},
+		"invalid path":    {Op: types.TransformSet, Path: []string{"$.spec.containers[foo]"}},
+		"invalid pattern": {Op: types.TransformDelete, Path: []string{"metadata", "/[/"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			config, _ := setupConfig()
+			config.Transform = types.Transforms{"Pod": {op}}
+			if err := config.Validate(); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func equality(a, b any) bool {
+	ya, _ := yaml.Marshal(a)
+	yb, _ := yaml.Marshal(b)
+	return string(ya) == string(yb)
+}
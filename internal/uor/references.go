@@ -0,0 +1,542 @@
+package uor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	amtypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/bakito/kubexporter/internal/client"
+	"github.com/bakito/kubexporter/internal/render"
+	"github.com/bakito/kubexporter/internal/types"
+	"github.com/bakito/kubexporter/internal/utils"
+	"github.com/bakito/kubexporter/internal/vfs"
+)
+
+const (
+	kindSecret                = "Secret"
+	kindConfigMap             = "ConfigMap"
+	kindServiceAccount        = "ServiceAccount"
+	kindPersistentVolume      = "PersistentVolume"
+	kindPersistentVolumeClaim = "PersistentVolumeClaim"
+	kindService               = "Service"
+	groupRBAC                 = "rbac.authorization.k8s.io"
+)
+
+// Mapping maps cluster-specific values of the source cluster to the values of the target cluster.
+type Mapping struct {
+	// Namespaces old to new namespace names.
+	Namespaces map[string]string `json:"namespaces" yaml:"namespaces"`
+	// Names per group kind old to new object names; the old name may be qualified with the namespace 'ns/name'.
+	Names map[string]map[string]string `json:"names" yaml:"names"`
+	// UIDs old to new uids.
+	UIDs map[string]string `json:"uids" yaml:"uids"`
+	// StorageClasses old to new storage class names.
+	StorageClasses map[string]string `json:"storageClasses" yaml:"storageClasses"`
+}
+
+// ReadMapping reads the mapping file.
+func ReadMapping(path string) (*Mapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mapping{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("error reading mapping file %q: %w", path, err)
+	}
+	return m, nil
+}
+
+func (m *Mapping) name(groupKind, namespace, name string) string {
+	names, ok := m.Names[groupKind]
+	if !ok {
+		return name
+	}
+	if n, ok := names[namespace+"/"+name]; ok && namespace != "" {
+		return n
+	}
+	if n, ok := names[name]; ok {
+		return n
+	}
+	return name
+}
+
+func (m *Mapping) storageClass(sc string) string {
+	if n, ok := m.StorageClasses[sc]; ok {
+		return n
+	}
+	return sc
+}
+
+// ReferenceChange a rewritten reference.
+type ReferenceChange struct {
+	File      string `json:"file"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Field     string `json:"field"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// refRule describes where an object references other objects.
+type refRule struct {
+	// path to the map(s) holding the reference, slices are traversed
+	path []string
+	// kind the referenced group kind, if empty it is read from the 'kind' and 'apiGroup' fields
+	kind string
+	// name the field holding the referenced name
+	name string
+	// namespace the field holding the referenced namespace, used to look up qualified name mappings
+	namespace string
+	// storageClass the field holding a storage class name
+	storageClass string
+}
+
+var (
+	podSpecRules = []refRule{
+		{name: "serviceAccountName", kind: kindServiceAccount},
+		{name: "serviceAccount", kind: kindServiceAccount},
+		{path: []string{"imagePullSecrets"}, name: "name", kind: kindSecret},
+		{path: []string{"volumes", "secret"}, name: "secretName", kind: kindSecret},
+		{path: []string{"volumes", "configMap"}, name: "name", kind: kindConfigMap},
+		{path: []string{"volumes", "persistentVolumeClaim"}, name: "claimName", kind: kindPersistentVolumeClaim},
+		{path: []string{"containers", "envFrom", "secretRef"}, name: "name", kind: kindSecret},
+		{path: []string{"containers", "envFrom", "configMapRef"}, name: "name", kind: kindConfigMap},
+		{path: []string{"containers", "env", "valueFrom", "secretKeyRef"}, name: "name", kind: kindSecret},
+		{path: []string{"containers", "env", "valueFrom", "configMapKeyRef"}, name: "name", kind: kindConfigMap},
+		{path: []string{"initContainers", "envFrom", "secretRef"}, name: "name", kind: kindSecret},
+		{path: []string{"initContainers", "envFrom", "configMapRef"}, name: "name", kind: kindConfigMap},
+		{path: []string{"initContainers", "env", "valueFrom", "secretKeyRef"}, name: "name", kind: kindSecret},
+		{path: []string{"initContainers", "env", "valueFrom", "configMapKeyRef"}, name: "name", kind: kindConfigMap},
+	}
+
+	bindingRules = []refRule{
+		{path: []string{"subjects"}, name: "name", namespace: "namespace"},
+		{path: []string{"roleRef"}, name: "name"},
+	}
+
+	// referenceRules well-known references per group kind.
+	referenceRules = map[string][]refRule{
+		kindServiceAccount: {
+			{path: []string{"secrets"}, name: "name", kind: kindSecret},
+			{path: []string{"imagePullSecrets"}, name: "name", kind: kindSecret},
+		},
+		kindPersistentVolumeClaim: {
+			{path: []string{"spec"}, name: "volumeName", kind: kindPersistentVolume, storageClass: "storageClassName"},
+		},
+		kindPersistentVolume: {
+			{path: []string{"spec"}, storageClass: "storageClassName"},
+			{path: []string{"spec", "claimRef"}, name: "name", namespace: "namespace", kind: kindPersistentVolumeClaim},
+		},
+		groupRBAC + ".RoleBinding":        bindingRules,
+		groupRBAC + ".ClusterRoleBinding": bindingRules,
+		"Pod":                             prefixRules([]string{"spec"}, podSpecRules),
+		"apps.Deployment":                 prefixRules([]string{"spec", "template", "spec"}, podSpecRules),
+		"apps.DaemonSet":                  prefixRules([]string{"spec", "template", "spec"}, podSpecRules),
+		"apps.ReplicaSet":                 prefixRules([]string{"spec", "template", "spec"}, podSpecRules),
+		"apps.StatefulSet": append(
+			prefixRules([]string{"spec", "template", "spec"}, podSpecRules),
+			refRule{path: []string{"spec", "volumeClaimTemplates", "spec"}, storageClass: "storageClassName"},
+		),
+		"batch.Job":     prefixRules([]string{"spec", "template", "spec"}, podSpecRules),
+		"batch.CronJob": prefixRules([]string{"spec", "jobTemplate", "spec", "template", "spec"}, podSpecRules),
+	}
+)
+
+func prefixRules(prefix []string, rules []refRule) []refRule {
+	prefixed := make([]refRule, len(rules))
+	for i, r := range rules {
+		r.path = append(append([]string{}, prefix...), r.path...)
+		prefixed[i] = r
+	}
+	return prefixed
+}
+
+// Rewrite rewrites the cluster-specific references of the export in the config target directory or archive.
+// Unless offline, live values are resolved from the current cluster.
+func Rewrite(ctx context.Context, config *types.Config, mapping *Mapping, offline bool, opts Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	err := config.Validate()
+	if err != nil {
+		return err
+	}
+
+	fsys, err := vfs.Open(config.Target, opts.ArchiveOut)
+	if err != nil {
+		return err
+	}
+
+	files, err := readFiles(fsys, "."+config.OutputFormat())
+	if err != nil {
+		return err
+	}
+
+	// namespace mappings of the config complement the mapping file
+	for from, to := range config.NamespaceMapping {
+		if _, ok := mapping.Namespaces[from]; !ok {
+			if mapping.Namespaces == nil {
+				mapping.Namespaces = make(map[string]string)
+			}
+			mapping.Namespaces[from] = to
+		}
+	}
+
+	rw := &rewriter{mapping: mapping}
+	if !offline {
+		ac, err := client.NewAPIClient(config)
+		if err != nil {
+			return err
+		}
+		rw.resolver = newResolver(ac.Client, ac.Mapper)
+	}
+
+	for _, f := range files {
+		if rw.rewrite(ctx, f) && !opts.DryRun {
+			if err := utils.WriteAllFS(config.PrintFlags, fsys, f.name, f.objects); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !opts.DryRun {
+		if err := fsys.Close(); err != nil {
+			return err
+		}
+	}
+
+	return printReferenceChanges(os.Stdout, opts, rw.changes)
+}
+
+type rewriter struct {
+	mapping  *Mapping
+	resolver *resolver
+	changes  []ReferenceChange
+	// current object state
+	file      string
+	us        *unstructured.Unstructured
+	namespace string
+	name      string
+	fields    map[string]bool
+	changed   bool
+}
+
+// record a replaced value, the file is changed.
+func (rw *rewriter) record(field, from, to string) {
+	rw.report(field, from, to)
+	rw.fields[field] = true
+	rw.changed = true
+}
+
+// report a reference without changing the file.
+func (rw *rewriter) report(field, from, to string) {
+	rw.changes = append(rw.changes, ReferenceChange{
+		File:      rw.file,
+		Namespace: rw.namespace,
+		Kind:      rw.us.GetKind(),
+		Name:      rw.name,
+		Field:     field,
+		From:      from,
+		To:        to,
+	})
+}
+
+// rewrite all objects of the file and return true if the file was changed.
+func (rw *rewriter) rewrite(ctx context.Context, f *file) bool {
+	rw.file = f.name
+	rw.changed = false
+	for _, us := range f.items() {
+		rw.us = us
+		rw.namespace = us.GetNamespace()
+		rw.name = us.GetName()
+		rw.fields = make(map[string]bool)
+		rw.rewriteObject(ctx)
+	}
+	return rw.changed
+}
+
+func (rw *rewriter) rewriteObject(ctx context.Context) {
+	us := rw.us
+	gk := groupKind(us)
+
+	if name := rw.mapping.name(gk, rw.namespace, rw.name); name != rw.name {
+		us.SetName(name)
+		rw.record("metadata.name", rw.name, name)
+	}
+
+	for _, rule := range referenceRules[gk] {
+		visitMaps(us.Object, rule.path, func(m map[string]any) {
+			rw.applyRule(rule, m)
+		})
+	}
+
+	// namespaces are mapped after the names, as names may be qualified with the source namespace
+	mapped, unmapped := types.NamespaceMapping(rw.mapping.Namespaces).Apply(us)
+	for _, ref := range mapped {
+		rw.record(ref.Field, ref.From, ref.To)
+	}
+	for _, ref := range unmapped {
+		rw.report(ref.Field, ref.From, "<UNMAPPED: "+ref.Reason+">")
+	}
+
+	rw.rewriteOwnerReferences(ctx)
+
+	if rw.resolver != nil {
+		rw.resolveLiveValues(ctx, gk)
+	}
+}
+
+func (rw *rewriter) rewriteOwnerReferences(ctx context.Context) {
+	refs := rw.us.GetOwnerReferences()
+	if len(refs) == 0 {
+		return
+	}
+	for i := range refs {
+		ref := &refs[i]
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+		refGK := groupKindOf(gv.Group, ref.Kind)
+		if name := rw.mapping.name(refGK, rw.namespace, ref.Name); name != ref.Name {
+			rw.record("metadata.ownerReferences.name", ref.Name, name)
+			ref.Name = name
+		}
+
+		uid := string(ref.UID)
+		if newUID, ok := rw.mapping.UIDs[uid]; ok {
+			uid = newUID
+		} else if rw.resolver != nil {
+			key := ownerKey(rw.us.GetNamespace(), ref)
+			o, ok := rw.resolver.owners[key]
+			if !ok {
+				o = &owner{}
+				o.uid, o.err = rw.resolver.findOwner(ctx, rw.us.GetNamespace(), ref)
+				rw.resolver.owners[key] = o
+			}
+			if o.err == nil {
+				uid = string(o.uid)
+			}
+		}
+		if uid != string(ref.UID) {
+			rw.record("metadata.ownerReferences.uid", string(ref.UID), uid)
+			ref.UID = amtypes.UID(uid)
+		}
+	}
+	rw.us.SetOwnerReferences(refs)
+}
+
+func (rw *rewriter) applyRule(rule refRule, m map[string]any) {
+	field := strings.Join(rule.path, ".")
+	if field != "" {
+		field += "."
+	}
+
+	refNamespace := rw.namespace
+	if rule.namespace != "" {
+		if ns, ok := m[rule.namespace].(string); ok {
+			refNamespace = ns
+		}
+	}
+
+	if rule.name != "" {
+		if name, ok := m[rule.name].(string); ok {
+			kind := rule.kind
+			if kind == "" {
+				group, _ := m["apiGroup"].(string)
+				k, _ := m["kind"].(string)
+				kind = groupKindOf(group, k)
+			}
+			if newName := rw.mapping.name(kind, refNamespace, name); newName != name {
+				m[rule.name] = newName
+				rw.record(field+rule.name, name, newName)
+			}
+		}
+	}
+
+	if rule.storageClass != "" {
+		if sc, ok := m[rule.storageClass].(string); ok {
+			if newSC := rw.mapping.storageClass(sc); newSC != sc {
+				m[rule.storageClass] = newSC
+				rw.record(field+rule.storageClass, sc, newSC)
+			}
+		}
+	}
+}
+
+// resolveLiveValues replaces values only valid in the source cluster with the values of the live object.
+// If the object does not exist in the target cluster, the values are removed to be assigned by the cluster.
+func (rw *rewriter) resolveLiveValues(ctx context.Context, gk string) {
+	var fields [][]string
+	switch gk {
+	case kindService:
+		if ip, _, _ := unstructured.NestedString(rw.us.Object, "spec", "clusterIP"); ip == "None" {
+			// headless services keep their cluster ip
+			return
+		}
+		fields = [][]string{{"spec", "clusterIP"}, {"spec", "clusterIPs"}}
+	case kindPersistentVolumeClaim:
+		fields = [][]string{{"spec", "volumeName"}}
+	case kindServiceAccount:
+		rw.resolveTokenSecrets(ctx)
+		return
+	default:
+		return
+	}
+
+	live, err := rw.resolver.get(ctx, rw.us.GroupVersionKind(), rw.us.GetNamespace(), rw.us.GetName())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return
+	}
+
+	for _, f := range fields {
+		current, ok, _ := unstructured.NestedFieldNoCopy(rw.us.Object, f...)
+		if !ok || rw.fields[strings.Join(f, ".")] {
+			// explicitly mapped values are not overridden
+			continue
+		}
+		if live == nil {
+			unstructured.RemoveNestedField(rw.us.Object, f...)
+			rw.record(strings.Join(f, "."), valueString(current), "")
+			continue
+		}
+		if liveValue, ok, _ := unstructured.NestedFieldCopy(live.Object, f...); ok &&
+			valueString(liveValue) != valueString(current) {
+			_ = unstructured.SetNestedField(rw.us.Object, liveValue, f...)
+			rw.record(strings.Join(f, "."), valueString(current), valueString(liveValue))
+		}
+	}
+}
+
+// resolveTokenSecrets replaces the generated token secrets of a ServiceAccount with the token secrets of the live
+// object, other secrets are kept. If the ServiceAccount does not exist, the token secrets are removed.
+func (rw *rewriter) resolveTokenSecrets(ctx context.Context) {
+	const field = "secrets.name"
+	secrets, ok, _ := unstructured.NestedSlice(rw.us.Object, "secrets")
+	if !ok || rw.fields[field] {
+		// explicitly mapped values are not overridden
+		return
+	}
+
+	kept, tokens := splitTokenSecrets(secrets, rw.name)
+	if len(tokens) == 0 {
+		return
+	}
+
+	live, err := rw.resolver.get(ctx, rw.us.GroupVersionKind(), rw.us.GetNamespace(), rw.us.GetName())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return
+	}
+	var liveTokens []string
+	if live != nil {
+		liveSecrets, _, _ := unstructured.NestedSlice(live.Object, "secrets")
+		_, liveTokens = splitTokenSecrets(liveSecrets, live.GetName())
+	}
+	if slices.Equal(tokens, liveTokens) {
+		return
+	}
+
+	for _, name := range liveTokens {
+		kept = append(kept, map[string]any{"name": name})
+	}
+	if len(kept) == 0 {
+		unstructured.RemoveNestedField(rw.us.Object, "secrets")
+	} else {
+		_ = unstructured.SetNestedSlice(rw.us.Object, kept, "secrets")
+	}
+	rw.record(field, strings.Join(tokens, ","), strings.Join(liveTokens, ","))
+}
+
+// splitTokenSecrets splits the secret references of a ServiceAccount into the names of the generated
+// '<service account>-token-<suffix>' secrets and all other references.
+func splitTokenSecrets(secrets []any, serviceAccount string) ([]any, []string) {
+	var other []any
+	var tokens []string
+	for _, s := range secrets {
+		if m, ok := s.(map[string]any); ok {
+			if name, _ := m["name"].(string); strings.HasPrefix(name, serviceAccount+"-token-") {
+				tokens = append(tokens, name)
+				continue
+			}
+		}
+		other = append(other, s)
+	}
+	return other, tokens
+}
+
+func printReferenceChanges(out io.Writer, opts Options, changes []ReferenceChange) error {
+	if opts.Output == OutputJSON {
+		if changes == nil {
+			changes = []ReferenceChange{}
+		}
+		return printJSON(out, changes)
+	}
+
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(out, "No changed references found")
+		return nil
+	}
+	if opts.DryRun {
+		_, _ = fmt.Fprintln(out, "Dry run: no files are changed")
+	}
+
+	table := render.Table()
+	table.Header("File", "Namespace", "Kind", "Name", "Field", "From", "To")
+	for _, c := range changes {
+		if err := table.Append(c.File, c.Namespace, c.Kind, c.Name, c.Field, c.From, c.To); err != nil {
+			return err
+		}
+	}
+	return table.Render()
+}
+
+// visitMaps calls fn for each map found at the path, slices on the way are traversed.
+func visitMaps(obj map[string]any, path []string, fn func(m map[string]any)) {
+	if len(path) == 0 {
+		fn(obj)
+		return
+	}
+	switch v := obj[path[0]].(type) {
+	case map[string]any:
+		visitMaps(v, path[1:], fn)
+	case []any:
+		for _, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				visitMaps(m, path[1:], fn)
+			}
+		}
+	}
+}
+
+func groupKind(us *unstructured.Unstructured) string {
+	return groupKindOf(us.GroupVersionKind().Group, us.GetKind())
+}
+
+func groupKindOf(group, kind string) string {
+	if group != "" {
+		return group + "." + kind
+	}
+	return kind
+}
+
+func valueString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if sl, ok := v.([]any); ok {
+		var s []string
+		for _, e := range sl {
+			s = append(s, fmt.Sprintf("%v", e))
+		}
+		return strings.Join(s, ",")
+	}
+	return fmt.Sprintf("%v", v)
+}
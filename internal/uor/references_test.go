@@ -0,0 +1,213 @@
+package uor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	gm "go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	mockdynamic "github.com/bakito/kubexporter/internal/mocks/client"
+	mockmeta "github.com/bakito/kubexporter/internal/mocks/mapper"
+)
+
+func TestRewriter_offline(t *testing.T) {
+	rb := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "RoleBinding",
+		"metadata": map[string]any{
+			"name":      "rb",
+			"namespace": "team-a-prod",
+		},
+		"roleRef": map[string]any{
+			"apiGroup": "rbac.authorization.k8s.io",
+			"kind":     "ClusterRole",
+			"name":     "old-role",
+		},
+		"subjects": []any{
+			map[string]any{"kind": "ServiceAccount", "name": "sa", "namespace": "team-a-prod"},
+			map[string]any{"kind": "ServiceAccount", "name": "other", "namespace": "monitoring"},
+		},
+	}}
+	pvc := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata": map[string]any{
+			"name":      "data",
+			"namespace": "team-a-prod",
+			"ownerReferences": []any{
+				map[string]any{"apiVersion": "apps/v1", "kind": "StatefulSet", "name": "db", "uid": "old-uid"},
+			},
+		},
+		"spec": map[string]any{
+			"storageClassName": "standard",
+			"volumeName":       "pv-1",
+		},
+	}}
+
+	rw := &rewriter{mapping: &Mapping{
+		Namespaces: map[string]string{"team-a-prod": "team-a-staging"},
+		Names: map[string]map[string]string{
+			"rbac.authorization.k8s.io.ClusterRole": {"old-role": "new-role"},
+			"ServiceAccount":                        {"monitoring/other": "renamed"},
+		},
+		UIDs:           map[string]string{"old-uid": "new-uid"},
+		StorageClasses: map[string]string{"standard": "fast"},
+	}}
+
+	if !rw.rewrite(context.TODO(), &file{name: "f.yaml", objects: []*unstructured.Unstructured{rb, pvc}}) {
+		t.Fatal("expected file to be changed")
+	}
+
+	checks := []struct {
+		obj      *unstructured.Unstructured
+		path     []string
+		expected string
+	}{
+		{rb, []string{"metadata", "namespace"}, "team-a-staging"},
+		{rb, []string{"roleRef", "name"}, "new-role"},
+		{pvc, []string{"metadata", "namespace"}, "team-a-staging"},
+		{pvc, []string{"spec", "storageClassName"}, "fast"},
+		{pvc, []string{"spec", "volumeName"}, "pv-1"},
+	}
+	for _, c := range checks {
+		if v, _, _ := unstructured.NestedString(c.obj.Object, c.path...); v != c.expected {
+			t.Errorf("%v = %q, want %q", c.path, v, c.expected)
+		}
+	}
+
+	subjects, _, _ := unstructured.NestedSlice(rb.Object, "subjects")
+	//nolint:forcetypeassert
+	if ns := subjects[0].(map[string]any)["namespace"]; ns != "team-a-staging" {
+		t.Errorf("subject namespace = %v, want team-a-staging", ns)
+	}
+	//nolint:forcetypeassert
+	if name := subjects[1].(map[string]any)["name"]; name != "renamed" {
+		t.Errorf("subject name = %v, want renamed", name)
+	}
+	if uid := pvc.GetOwnerReferences()[0].UID; uid != "new-uid" {
+		t.Errorf("owner uid = %q, want new-uid", uid)
+	}
+	if len(rw.changes) != 7 {
+		t.Errorf("expected 7 changes, got %d: %v", len(rw.changes), rw.changes)
+	}
+}
+
+func TestRewriter_liveValues(t *testing.T) {
+	ctrl := gm.NewController(t)
+	mockClient := mockdynamic.NewMockInterface(ctrl)
+	mockMapper := mockmeta.NewMockRESTMapper(ctrl)
+	services := mockdynamic.NewMockNamespaceableResourceInterface(ctrl)
+	nsServices := mockdynamic.NewMockResourceInterface(ctrl)
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	mockMapper.EXPECT().RESTMapping(schema.GroupKind{Kind: "Service"}, "v1").
+		Return(&meta.RESTMapping{Resource: gvr, Scope: meta.RESTScopeNamespace}, nil).Times(2)
+	mockClient.EXPECT().Resource(gvr).Return(services).Times(2)
+	services.EXPECT().Namespace("ns").Return(nsServices).Times(2)
+	nsServices.EXPECT().Get(gm.Any(), "live", gm.Any()).Return(&unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{"clusterIP": "10.0.0.2", "clusterIPs": []any{"10.0.0.2"}},
+	}}, nil)
+	nsServices.EXPECT().Get(gm.Any(), "missing", gm.Any()).
+		Return(nil, errors.NewNotFound(schema.GroupResource{Resource: "services"}, "missing"))
+
+	svc := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]any{"name": name, "namespace": "ns"},
+			"spec":       map[string]any{"clusterIP": "10.1.1.1", "clusterIPs": []any{"10.1.1.1"}},
+		}}
+	}
+	live, missing, headless := svc("live"), svc("missing"), svc("headless")
+	_ = unstructured.SetNestedField(headless.Object, "None", "spec", "clusterIP")
+
+	rw := &rewriter{mapping: &Mapping{}, resolver: newResolver(mockClient, mockMapper)}
+	rw.rewrite(context.TODO(), &file{name: "f.yaml", objects: []*unstructured.Unstructured{live, missing, headless}})
+
+	if ip, _, _ := unstructured.NestedString(live.Object, "spec", "clusterIP"); ip != "10.0.0.2" {
+		t.Errorf("expected live cluster ip, got %q", ip)
+	}
+	if _, ok, _ := unstructured.NestedFieldNoCopy(missing.Object, "spec", "clusterIP"); ok {
+		t.Error("expected cluster ip of missing service to be removed")
+	}
+	if ip, _, _ := unstructured.NestedString(headless.Object, "spec", "clusterIP"); ip != "None" {
+		t.Errorf("expected headless service to be unchanged, got %q", ip)
+	}
+}
+
+func TestRewriter_unmappedOnly(t *testing.T) {
+	cm := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "cm", "namespace": "other"},
+		"data":       map[string]any{"url": "http://db.team-a-prod.svc.cluster.local:5432"},
+	}}
+
+	rw := &rewriter{mapping: &Mapping{Namespaces: map[string]string{"team-a-prod": "team-a-staging"}}}
+	if rw.rewrite(context.TODO(), &file{name: "f.yaml", objects: []*unstructured.Unstructured{cm}}) {
+		t.Error("expected file with only unmapped references not to be changed")
+	}
+	if len(rw.changes) != 1 || rw.changes[0].To != "<UNMAPPED: service dns name in ConfigMap>" {
+		t.Errorf("expected the unmapped reference to be reported, got %v", rw.changes)
+	}
+}
+
+func TestRewriter_serviceAccountTokenSecrets(t *testing.T) {
+	ctrl := gm.NewController(t)
+	mockClient := mockdynamic.NewMockInterface(ctrl)
+	mockMapper := mockmeta.NewMockRESTMapper(ctrl)
+	sas := mockdynamic.NewMockNamespaceableResourceInterface(ctrl)
+	nsSAs := mockdynamic.NewMockResourceInterface(ctrl)
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}
+
+	mockMapper.EXPECT().RESTMapping(schema.GroupKind{Kind: "ServiceAccount"}, "v1").
+		Return(&meta.RESTMapping{Resource: gvr, Scope: meta.RESTScopeNamespace}, nil).Times(2)
+	mockClient.EXPECT().Resource(gvr).Return(sas).Times(2)
+	sas.EXPECT().Namespace("ns").Return(nsSAs).Times(2)
+	liveSA := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{"name": "live", "namespace": "ns"},
+		"secrets":  []any{map[string]any{"name": "live-token-new"}},
+	}}
+	nsSAs.EXPECT().Get(gm.Any(), "live", gm.Any()).Return(liveSA, nil)
+	nsSAs.EXPECT().Get(gm.Any(), "missing", gm.Any()).
+		Return(nil, errors.NewNotFound(schema.GroupResource{Resource: "serviceaccounts"}, "missing"))
+
+	sa := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   map[string]any{"name": name, "namespace": "ns"},
+			"secrets": []any{
+				map[string]any{"name": name + "-token-old"},
+				map[string]any{"name": "custom"},
+			},
+		}}
+	}
+	live, missing := sa("live"), sa("missing")
+
+	rw := &rewriter{mapping: &Mapping{}, resolver: newResolver(mockClient, mockMapper)}
+	if !rw.rewrite(context.TODO(), &file{name: "f.yaml", objects: []*unstructured.Unstructured{live, missing}}) {
+		t.Fatal("expected file to be changed")
+	}
+
+	names := func(us *unstructured.Unstructured) []string {
+		secrets, _, _ := unstructured.NestedSlice(us.Object, "secrets")
+		var n []string
+		for _, s := range secrets {
+			//nolint:forcetypeassert
+			n = append(n, s.(map[string]any)["name"].(string))
+		}
+		return n
+	}
+	if got := names(live); !reflect.DeepEqual(got, []string{"custom", "live-token-new"}) {
+		t.Errorf("expected the live token secret, got %v", got)
+	}
+	if got := names(missing); !reflect.DeepEqual(got, []string{"custom"}) {
+		t.Errorf("expected the token secret to be removed, got %v", got)
+	}
+}
@@ -2,41 +2,90 @@ package uor
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
-	"strings"
+	"sync"
 
-	"github.com/olekukonko/tablewriter"
-	"k8s.io/apimachinery/pkg/api/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	amtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 
 	"github.com/bakito/kubexporter/internal/client"
 	"github.com/bakito/kubexporter/internal/render"
 	"github.com/bakito/kubexporter/internal/types"
 	"github.com/bakito/kubexporter/internal/utils"
+	"github.com/bakito/kubexporter/internal/vfs"
 )
 
-func Update(ctx context.Context, config *types.Config) error {
+const (
+	// OutputTable print the changes as table.
+	OutputTable = "table"
+	// OutputJSON print the changes as json.
+	OutputJSON = "json"
+
+	errNotFound = "<NOT FOUND>"
+	errGeneric  = "<ERROR>"
+)
+
+// Options update options.
+type Options struct {
+	// ArchiveOut if set, a changed archive is written to this path.
+	ArchiveOut string
+	// DryRun print the changes without writing them.
+	DryRun bool
+	// Output the output format table|json.
+	Output string
+}
+
+func (o Options) validate() error {
+	if o.Output != "" && o.Output != OutputTable && o.Output != OutputJSON {
+		return fmt.Errorf("invalid report format %q supported are: [%s/%s]", o.Output, OutputTable, OutputJSON)
+	}
+	return nil
+}
+
+func printJSON(out io.Writer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Change a changed or unresolvable owner reference.
+type Change struct {
+	File      string `json:"file"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	OwnerKind string `json:"ownerKind"`
+	OwnerName string `json:"ownerName"`
+	UIDFrom   string `json:"uidFrom"`
+	UIDTo     string `json:"uidTo,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Update updates the owner references of the export in the config target directory or archive.
+func Update(ctx context.Context, config *types.Config, opts Options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
 	err := config.Validate()
 	if err != nil {
 		return err
 	}
 
-	var files []string
-	err = filepath.Walk(config.Target, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if !info.IsDir() && filepath.Ext(path) == "."+config.OutputFormat() {
-			files = append(files, path)
-		}
+	fsys, err := vfs.Open(config.Target, opts.ArchiveOut)
+	if err != nil {
+		return err
+	}
 
-		return nil
-	})
+	files, err := readFiles(fsys, "."+config.OutputFormat())
 	if err != nil {
 		return err
 	}
@@ -46,115 +95,220 @@ func Update(ctx context.Context, config *types.Config) error {
 		return err
 	}
 
-	table := render.Table()
-	table.Header("File", "Owner Kind", "Owner Name", "UID From", "UID To")
+	r := newResolver(ac.Client, ac.Mapper)
+	r.resolve(ctx, files, config.Worker)
 
-	hasRows := false
-	for _, file := range files {
-		rows, err := updateFile(ctx, config, file, ac, table)
-		if err != nil {
+	var changes []Change
+	for _, f := range files {
+		fc, changed := r.update(f)
+		changes = append(changes, fc...)
+		if changed && !opts.DryRun {
+			if err := utils.WriteAllFS(config.PrintFlags, fsys, f.name, f.objects); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !opts.DryRun {
+		if err := fsys.Close(); err != nil {
 			return err
 		}
-		hasRows = hasRows || rows
 	}
 
-	if !hasRows {
-		fmt.Println("No changed owner references found")
+	return printChanges(os.Stdout, opts, changes)
+}
+
+func printChanges(out io.Writer, opts Options, changes []Change) error {
+	if opts.Output == OutputJSON {
+		if changes == nil {
+			changes = []Change{}
+		}
+		return printJSON(out, changes)
 	}
 
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(out, "No changed owner references found")
+		return nil
+	}
+	if opts.DryRun {
+		_, _ = fmt.Fprintln(out, "Dry run: no files are changed")
+	}
+
+	table := render.Table()
+	table.Header("File", "Owner Kind", "Owner Name", "UID From", "UID To")
+	for _, c := range changes {
+		to := c.UIDTo
+		if c.Error != "" {
+			to = c.Error
+		}
+		if err := table.Append(c.File, c.OwnerKind, c.OwnerName, c.UIDFrom, to); err != nil {
+			return err
+		}
+	}
 	return table.Render()
 }
 
-func updateFile(
-	ctx context.Context,
-	config *types.Config,
-	file string,
-	ac *client.APIClient,
-	table *tablewriter.Table,
-) (bool, error) {
-	fileName := strings.Replace(file, config.Target+"/", "", 1)
-	us, err := utils.ReadFile(file)
+type file struct {
+	name    string
+	objects []*unstructured.Unstructured
+}
+
+// items returns all objects of the file, list items are returned instead of their list.
+func (f *file) items() []*unstructured.Unstructured {
+	return utils.ListItems(f.objects...)
+}
+
+func readFiles(fsys vfs.FS, ext string) ([]*file, error) {
+	names, err := fsys.Files(ext)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	refs := us.GetOwnerReferences()
-	owners := make(map[string]*unstructured.Unstructured)
-	changed := false
-	witContent := false
-	if len(refs) > 0 {
-		for _, ref := range refs {
-			owner, err := findOwner(ctx, ac, owners, &ref, us)
-			if err != nil {
-				errMsg := "<ERROR>"
-				if errors.IsNotFound(err) {
-					errMsg = "<NOT FOUND>"
-				}
-				if err := table.Append(
-					fileName,
-					ref.Kind,
-					ref.Name,
-					string(ref.UID),
-					errMsg,
-				); err != nil {
-					return false, err
+	files := make([]*file, 0, len(names))
+	for _, name := range names {
+		objs, err := utils.ReadAllFS(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %q: %w", fsys.Path(name), err)
+		}
+		files = append(files, &file{name: name, objects: objs})
+	}
+	return files, nil
+}
+
+type owner struct {
+	uid amtypes.UID
+	err error
+}
+
+type resolver struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+	owners map[string]*owner
+}
+
+func newResolver(dc dynamic.Interface, mapper meta.RESTMapper) *resolver {
+	return &resolver{
+		client: dc,
+		mapper: mapper,
+		owners: make(map[string]*owner),
+	}
+}
+
+func ownerKey(namespace string, ref *metav1.OwnerReference) string {
+	return namespace + "#" + ref.APIVersion + "#" + ref.Kind + "#" + ref.Name
+}
+
+// resolve looks up all owners referenced by the files with the given number of concurrent lookups.
+func (r *resolver) resolve(ctx context.Context, files []*file, workers int) {
+	type lookup struct {
+		key       string
+		namespace string
+		ref       metav1.OwnerReference
+	}
+	var lookups []lookup
+	for _, f := range files {
+		for _, us := range f.items() {
+			for _, ref := range us.GetOwnerReferences() {
+				key := ownerKey(us.GetNamespace(), &ref)
+				if _, ok := r.owners[key]; !ok {
+					r.owners[key] = &owner{}
+					lookups = append(lookups, lookup{key: key, namespace: us.GetNamespace(), ref: ref})
 				}
-				witContent = true
-				continue
 			}
+		}
+	}
 
-			if ref.UID != owner.GetUID() {
-				if err := table.Append(
-					fileName,
-					ref.Kind,
-					ref.Name,
-					string(ref.UID),
-					string(owner.GetUID()),
-				); err != nil {
-					return false, err
+	if workers <= 0 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for _, l := range lookups {
+		o := r.owners[l.key]
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+			o.uid, o.err = r.findOwner(ctx, l.namespace, &l.ref)
+		})
+	}
+	wg.Wait()
+}
+
+// update sets the resolved owner uids and returns the changes and whether the file was changed.
+func (r *resolver) update(f *file) ([]Change, bool) {
+	var changes []Change
+	changed := false
+	for _, us := range f.items() {
+		refs := us.GetOwnerReferences()
+		objChanged := false
+		for i := range refs {
+			ref := &refs[i]
+			o := r.owners[ownerKey(us.GetNamespace(), ref)]
+			c := Change{
+				File:      f.name,
+				Namespace: us.GetNamespace(),
+				Kind:      us.GetKind(),
+				Name:      us.GetName(),
+				OwnerKind: ref.Kind,
+				OwnerName: ref.Name,
+				UIDFrom:   string(ref.UID),
+			}
+			switch {
+			case o.err != nil:
+				c.Error = errGeneric
+				if apierrors.IsNotFound(o.err) {
+					c.Error = errNotFound
 				}
-				witContent = true
-				ref.UID = owner.GetUID()
-				changed = true
+			case ref.UID != o.uid:
+				c.UIDTo = string(o.uid)
+				ref.UID = o.uid
+				objChanged = true
+			default:
+				continue
 			}
+			changes = append(changes, c)
 		}
-		if changed {
+		if objChanged {
 			us.SetOwnerReferences(refs)
-			err := utils.WriteFile(config.PrintFlags, file, us)
-			if err != nil {
-				return false, err
-			}
+			changed = true
 		}
 	}
-	return witContent, nil
+	return changes, changed
 }
 
-func findOwner(
-	ctx context.Context,
-	ac *client.APIClient,
-	owners map[string]*unstructured.Unstructured,
-	ref *metav1.OwnerReference,
-	us *unstructured.Unstructured,
-) (*unstructured.Unstructured, error) {
-	key := us.GetNamespace() + "#" + ref.APIVersion + "#" + ref.Name
-	if owner, ok := owners[key]; ok {
-		return owner, nil
-	}
-
+// findOwner gets the owner uid from the cluster.
+func (r *resolver) findOwner(ctx context.Context, namespace string, ref *metav1.OwnerReference) (amtypes.UID, error) {
 	gv, err := schema.ParseGroupVersion(ref.APIVersion)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	mapping, err := ac.Mapper.RESTMapping(schema.GroupKind{
-		Group: gv.Group,
-		Kind:  ref.Kind,
-	}, gv.Version)
+	o, err := r.get(ctx, gv.WithKind(ref.Kind), namespace, ref.Name)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	owner, err := ac.Client.Resource(mapping.Resource).Namespace(us.GetNamespace()).Get(ctx, ref.Name, metav1.GetOptions{})
+	return o.GetUID(), nil
+}
+
+// get an object from the cluster, the scope is resolved via the rest mapper,
+// cluster-scoped objects are queried without namespace.
+func (r *resolver) get(
+	ctx context.Context,
+	gvk schema.GroupVersionKind,
+	namespace, name string,
+) (*unstructured.Unstructured, error) {
+	mapping, err := r.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
 		return nil, err
 	}
-	owners[key] = owner
-	return owner, nil
+
+	var ri dynamic.ResourceInterface
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if namespace == "" {
+			return nil, errors.New("namespaced object referenced by a cluster-scoped resource")
+		}
+		ri = r.client.Resource(mapping.Resource).Namespace(namespace)
+	} else {
+		ri = r.client.Resource(mapping.Resource)
+	}
+
+	return ri.Get(ctx, name, metav1.GetOptions{})
 }
@@ -0,0 +1,122 @@
+package uor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gm "go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	amtypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	mockdynamic "github.com/bakito/kubexporter/internal/mocks/client"
+	mockmeta "github.com/bakito/kubexporter/internal/mocks/mapper"
+	"github.com/bakito/kubexporter/internal/types"
+	"github.com/bakito/kubexporter/internal/utils"
+	"github.com/bakito/kubexporter/internal/vfs"
+)
+
+const multiDoc = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+  namespace: ns
+  ownerReferences:
+  - apiVersion: v1
+    kind: Node
+    name: node1
+    uid: old-node-uid
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: cm2
+    namespace: ns
+    ownerReferences:
+    - apiVersion: apps/v1
+      kind: Deployment
+      name: deploy
+      uid: old-deploy-uid
+`
+
+func ownerWithUID(uid string) *unstructured.Unstructured {
+	us := &unstructured.Unstructured{Object: map[string]any{}}
+	us.SetUID(amtypes.UID(uid))
+	return us
+}
+
+func TestUpdate(t *testing.T) {
+	ctrl := gm.NewController(t)
+	mockClient := mockdynamic.NewMockInterface(ctrl)
+	mockMapper := mockmeta.NewMockRESTMapper(ctrl)
+	nodes := mockdynamic.NewMockNamespaceableResourceInterface(ctrl)
+	deployments := mockdynamic.NewMockNamespaceableResourceInterface(ctrl)
+	nsDeployments := mockdynamic.NewMockResourceInterface(ctrl)
+
+	nodeGVR := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	deployGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	mockMapper.EXPECT().RESTMapping(schema.GroupKind{Kind: "Node"}, "v1").
+		Return(&meta.RESTMapping{Resource: nodeGVR, Scope: meta.RESTScopeRoot}, nil)
+	mockMapper.EXPECT().RESTMapping(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "v1").
+		Return(&meta.RESTMapping{Resource: deployGVR, Scope: meta.RESTScopeNamespace}, nil)
+	mockClient.EXPECT().Resource(nodeGVR).Return(nodes)
+	mockClient.EXPECT().Resource(deployGVR).Return(deployments)
+	// cluster-scoped owners must be queried without namespace
+	nodes.EXPECT().Get(gm.Any(), "node1", gm.Any()).Return(ownerWithUID("new-node-uid"), nil)
+	deployments.EXPECT().Namespace("ns").Return(nsDeployments)
+	nsDeployments.EXPECT().Get(gm.Any(), "deploy", gm.Any()).Return(ownerWithUID("new-deploy-uid"), nil)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ns.yaml"), []byte(multiDoc), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fsys := vfs.Dir(dir)
+	files, err := readFiles(fsys, ".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newResolver(mockClient, mockMapper)
+	r.resolve(context.TODO(), files, 2)
+
+	changes, changed := r.update(files[0])
+	if !changed {
+		t.Error("expected file to be changed")
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %d", len(changes))
+	}
+	if changes[0].UIDTo != "new-node-uid" || changes[1].UIDTo != "new-deploy-uid" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if changes[1].Name != "cm2" {
+		t.Errorf("expected list item cm2 to be changed, got %q", changes[1].Name)
+	}
+
+	pf := &genericclioptions.PrintFlags{
+		OutputFormat:       new(types.DefaultFormat),
+		JSONYamlPrintFlags: genericclioptions.NewJSONYamlPrintFlags(),
+	}
+	if err := utils.WriteAllFS(pf, fsys, files[0].name, files[0].objects); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "ns.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(b)
+	for _, s := range []string{"uid: new-node-uid", "uid: new-deploy-uid", "\n---\n", "kind: List"} {
+		if !strings.Contains(content, s) {
+			t.Errorf("expected content to contain %q\n%s", s, content)
+		}
+	}
+}
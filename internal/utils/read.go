@@ -2,6 +2,8 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"io"
 	"os"
 
@@ -9,25 +11,77 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/bakito/kubexporter/internal/vfs"
 )
 
 func ReadFile(file string) (*unstructured.Unstructured, error) {
-	us := &unstructured.Unstructured{}
-
 	f, err := os.Open(file)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	decoder := yaml.NewYAMLOrJSONDecoder(bufio.NewReader(f), 20)
-	err = decoder.Decode(us)
-	if err != nil {
+	return Read(f)
+}
+
+// Read decodes a single yaml or json object from the reader.
+func Read(r io.Reader) (*unstructured.Unstructured, error) {
+	us := &unstructured.Unstructured{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 20)
+	if err := decoder.Decode(us); err != nil {
 		return nil, err
 	}
 	return us, nil
 }
 
+// ReadAll decodes all yaml or json documents from the reader.
+func ReadAll(r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bufio.NewReader(r), 20)
+	for {
+		us := &unstructured.Unstructured{}
+		err := decoder.Decode(us)
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(us.Object) > 0 {
+			objs = append(objs, us)
+		}
+	}
+}
+
+// ReadAllFS reads all objects from the named file of the file system.
+func ReadAllFS(fsys vfs.FS, name string) ([]*unstructured.Unstructured, error) {
+	b, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return ReadAll(bytes.NewReader(b))
+}
+
+// ListItems returns the objects, list items are returned instead of their list.
+// The items share their content with the list, so changes to the items are applied to the list.
+func ListItems(objs ...*unstructured.Unstructured) []*unstructured.Unstructured {
+	var items []*unstructured.Unstructured
+	for _, us := range objs {
+		if !us.IsList() {
+			items = append(items, us)
+			continue
+		}
+		_ = us.EachListItem(func(o runtime.Object) error {
+			if item, ok := o.(*unstructured.Unstructured); ok {
+				items = append(items, item)
+			}
+			return nil
+		})
+	}
+	return items
+}
+
 func WriteFile(printFlags *genericclioptions.PrintFlags, file string, us *unstructured.Unstructured) error {
 	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o666)
 	if err != nil {
@@ -41,6 +95,73 @@ func WriteFile(printFlags *genericclioptions.PrintFlags, file string, us *unstru
 	return nil
 }
 
+// WriteAllFS writes the objects as multiple documents to the named file of the file system.
+func WriteAllFS(
+	printFlags *genericclioptions.PrintFlags,
+	fsys vfs.FS,
+	name string,
+	objs []*unstructured.Unstructured,
+) error {
+	p, err := printFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, us := range objs {
+		// the yaml printer separates consecutive documents by itself
+		if err := p.PrintObj(us, &buf); err != nil {
+			return err
+		}
+	}
+	return fsys.WriteFile(name, buf.Bytes())
+}
+
+// WalkFiles calls fn for each given file. Directories and archives are opened as file system and fn is called for each
+// contained file with the given extension. If archiveOut is set, the changed archive is written to this path.
+func WalkFiles(ext, archiveOut string, files []string, fn func(fsys vfs.FS, name string) error) error {
+	var archives int
+	for _, file := range files {
+		if vfs.IsArchive(file) {
+			archives++
+		}
+	}
+	if archiveOut != "" && (archives != 1 || len(files) != 1) {
+		return errors.New("an archive output can only be defined for a single archive")
+	}
+
+	for _, file := range files {
+		if !vfs.IsArchive(file) && !isDir(file) {
+			if err := fn(vfs.Dir(""), file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fsys, err := vfs.Open(file, archiveOut)
+		if err != nil {
+			return err
+		}
+		names, err := fsys.Files(ext)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := fn(fsys, name); err != nil {
+				return err
+			}
+		}
+		if err := fsys.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
 // PrintObj print the given object.
 func PrintObj(printFlags *genericclioptions.PrintFlags, ro runtime.Object, out io.Writer) error {
 	p, err := printFlags.ToPrinter()
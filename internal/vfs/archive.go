@@ -0,0 +1,161 @@
+package vfs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+type entry struct {
+	header *tar.Header
+	data   []byte
+}
+
+type archiveFS struct {
+	path    string
+	out     string
+	entries []*entry
+	index   map[string]*entry
+	changed bool
+}
+
+// OpenArchive reads the whole archive into memory, no entries are extracted to disk.
+// Changes are written as new archive to out when closed, if out is empty the original archive is replaced.
+func OpenArchive(archive, out string) (FS, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive %q: %w", archive, err)
+	}
+	defer gr.Close()
+
+	a := &archiveFS{
+		path:  archive,
+		out:   out,
+		index: make(map[string]*entry),
+	}
+	tr := tar.NewReader(gr)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive %q: %w", archive, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		e := &entry{header: h, data: data}
+		a.entries = append(a.entries, e)
+		a.index[h.Name] = e
+	}
+	return a, nil
+}
+
+func (a *archiveFS) Files(ext string) ([]string, error) {
+	var files []string
+	for _, e := range a.entries {
+		if e.header.Typeflag == tar.TypeReg && path.Ext(e.header.Name) == ext {
+			files = append(files, e.header.Name)
+		}
+	}
+	return files, nil
+}
+
+func (a *archiveFS) ReadFile(name string) ([]byte, error) {
+	e, ok := a.index[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: a.Path(name), Err: os.ErrNotExist}
+	}
+	return bytes.Clone(e.data), nil
+}
+
+func (a *archiveFS) WriteFile(name string, data []byte) error {
+	e, ok := a.index[name]
+	if !ok {
+		e = &entry{header: &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644}}
+		a.entries = append(a.entries, e)
+		a.index[name] = e
+	}
+	e.data = bytes.Clone(data)
+	e.header.Size = int64(len(data))
+	e.header.ModTime = time.Now()
+	a.changed = true
+	return nil
+}
+
+func (a *archiveFS) Path(name string) string {
+	return a.path + ":" + name
+}
+
+// Close writes the archive to out, the input archive is only replaced if entries were changed.
+func (a *archiveFS) Close() error {
+	if !a.changed && a.out == "" {
+		return nil
+	}
+	out := a.out
+	if out == "" {
+		out = a.path
+	}
+
+	// write to a temp file next to the target and rename, to never leave a partial archive behind
+	tmp, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	// temp files are created with 0600, keep the mode of an existing archive
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(out); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := a.write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		return err
+	}
+	a.changed = false
+	return nil
+}
+
+func (a *archiveFS) write(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, e := range a.entries {
+		if err := tw.WriteHeader(e.header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
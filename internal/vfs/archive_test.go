@@ -0,0 +1,210 @@
+package vfs_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/bakito/kubexporter/internal/vfs"
+)
+
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, name := range []string{"exports/ns/Secret.a.yaml", "exports/ns/ConfigMap.b.json", "exports/Node.c.yaml"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Typeflag: tar.TypeReg,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		out  string
+	}{
+		{
+			name: "should replace the input archive",
+		},
+		{
+			name: "should write a new archive",
+			out:  "new.tar.gz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "exports.tar.gz")
+			writeArchive(t, archive, map[string]string{
+				"exports/ns/Secret.a.yaml":    "kind: Secret\n",
+				"exports/ns/ConfigMap.b.json": "{}",
+				"exports/Node.c.yaml":         "kind: Node\n",
+			})
+
+			if !vfs.IsArchive(archive) {
+				t.Fatalf("expected %q to be an archive", archive)
+			}
+
+			out := ""
+			if tt.out != "" {
+				out = filepath.Join(dir, tt.out)
+			}
+			fsys, err := vfs.Open(archive, out)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			files, err := fsys.Files(".yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := []string{"exports/ns/Secret.a.yaml", "exports/Node.c.yaml"}
+			if !reflect.DeepEqual(files, expected) {
+				t.Errorf("Files() = %v, want %v", files, expected)
+			}
+
+			if err := fsys.WriteFile("exports/ns/Secret.a.yaml", []byte("kind: Secret\ndata: {}\n")); err != nil {
+				t.Fatal(err)
+			}
+			if err := fsys.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			check := archive
+			if out != "" {
+				check = out
+			}
+			fsys, err = vfs.OpenArchive(check, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := fsys.ReadFile("exports/ns/Secret.a.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "kind: Secret\ndata: {}\n" {
+				t.Errorf("ReadFile() = %q", string(b))
+			}
+			b, err = fsys.ReadFile("exports/ns/ConfigMap.b.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "{}" {
+				t.Errorf("ReadFile() = %q, want unchanged entry", string(b))
+			}
+			if _, err := fsys.ReadFile("missing.yaml"); !os.IsNotExist(err) {
+				t.Errorf("expected not exist error, got %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedEntries := 1
+			if out != "" {
+				expectedEntries = 2
+			}
+			if len(entries) != expectedEntries {
+				t.Errorf("expected %d files in dir, got %d", expectedEntries, len(entries))
+			}
+		})
+	}
+}
+
+func TestArchive_unchangedOutput(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "exports.tar.gz")
+	writeArchive(t, archive, map[string]string{"exports/ns/Secret.a.yaml": "kind: Secret\n"})
+	info, err := os.Stat(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "new.tar.gz")
+	fsys, err := vfs.Open(archive, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fsys, err = vfs.OpenArchive(out, "")
+	if err != nil {
+		t.Fatalf("expected the output archive to be written: %v", err)
+	}
+	if b, err := fsys.ReadFile("exports/ns/Secret.a.yaml"); err != nil || string(b) != "kind: Secret\n" {
+		t.Errorf("ReadFile() = %q, %v", string(b), err)
+	}
+
+	fsys, err = vfs.Open(archive, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := os.Stat(archive); err != nil || !after.ModTime().Equal(info.ModTime()) {
+		t.Error("expected the unchanged input archive not to be rewritten")
+	}
+}
+
+func TestArchive_mode(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "exports.tar.gz")
+	writeArchive(t, archive, map[string]string{"exports/ns/Secret.a.yaml": "kind: Secret\n"})
+	if err := os.Chmod(archive, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, expected := range map[string]os.FileMode{archive: 0o640, filepath.Join(dir, "new.tar.gz"): 0o644} {
+		out := ""
+		if path != archive {
+			out = path
+		}
+		fsys, err := vfs.Open(archive, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := fsys.WriteFile("exports/ns/Secret.a.yaml", []byte("kind: Secret\ndata: {}\n")); err != nil {
+			t.Fatal(err)
+		}
+		if err := fsys.Close(); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != expected {
+			t.Errorf("mode of %q = %v, want %v", filepath.Base(path), info.Mode().Perm(), expected)
+		}
+	}
+}
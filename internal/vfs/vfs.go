@@ -0,0 +1,88 @@
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FS a virtual file system over an export directory or a kubexporter archive.
+type FS interface {
+	// Files returns the names of all regular files with the given extension (e.g. ".yaml").
+	Files(ext string) ([]string, error)
+	// ReadFile reads the content of the named file.
+	ReadFile(name string) ([]byte, error)
+	// WriteFile replaces the content of the named file.
+	WriteFile(name string, data []byte) error
+	// Path returns a display path for the named file.
+	Path(name string) string
+	// Close persists pending changes.
+	Close() error
+}
+
+// IsArchive returns true if the path points to a tar.gz archive.
+func IsArchive(path string) bool {
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return false
+	}
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// Open opens the given path as directory or as archive.
+// Changes to an archive are written as new archive to out, if out is empty the original archive is replaced.
+func Open(path, out string) (FS, error) {
+	if IsArchive(path) {
+		return OpenArchive(path, out)
+	}
+	return Dir(path), nil
+}
+
+// Dir returns a file system rooted at the given directory.
+func Dir(root string) FS {
+	return &dirFS{root: root}
+}
+
+type dirFS struct {
+	root string
+}
+
+func (d *dirFS) Files(ext string) ([]string, error) {
+	root := d.root
+	if root == "" {
+		root = "."
+	}
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ext {
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (d *dirFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(d.Path(name))
+}
+
+func (d *dirFS) WriteFile(name string, data []byte) error {
+	return os.WriteFile(d.Path(name), data, 0o666)
+}
+
+func (d *dirFS) Path(name string) string {
+	if d.root == "" {
+		return name
+	}
+	return filepath.Join(d.root, name)
+}
+
+func (*dirFS) Close() error {
+	return nil
+}